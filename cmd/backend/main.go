@@ -2,17 +2,27 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"math"
 	"math/rand"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
 	"github.com/Pranshu258/OpenPrequal/pkg/contracts"
 	"github.com/Pranshu258/OpenPrequal/pkg/metrics"
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
+	"github.com/Pranshu258/OpenPrequal/pkg/probe/probepb"
 )
 
 func main() {
@@ -26,7 +36,9 @@ func main() {
 	}
 	url := fmt.Sprintf("http://%s:%s", host, port)
 
-	metricsManager := metrics.NewMetricsManager()
+	logger := observability.NewLogger("")
+	heartbeatLogger := logger.With(slog.String("component", "heartbeat_client"))
+	metricsManager := metrics.NewMetricsManager(logger.With(slog.String("component", "metrics_manager")))
 
 	// Load simulation parameters
 	const (
@@ -61,7 +73,7 @@ func main() {
 		totalLatencyMs := jitterMultiplier * (baseLatency + rifJitter)
 		simulatedLatency := time.Duration(totalLatencyMs * float64(time.Millisecond))
 
-		log.Printf("[Backend] Processing request with RIF=%d, simulated latency=%.3fms", rifCount, totalLatencyMs)
+		logger.Debug("processing request", slog.Int("rif", rifCount), slog.Float64("simulated_latency_ms", totalLatencyMs))
 
 		// Simulate the work
 		time.Sleep(simulatedLatency)
@@ -80,43 +92,104 @@ func main() {
 		rifKeyedLatency := metricsManager.GetAvgLatencyForRIF(inFlight)
 		rifKeyedLatencyMs := float64(rifKeyedLatency.Nanoseconds()) / 1e6
 
-		log.Printf("[/metrics] Reporting: InFlight=%d AvgLatency=%.3f ms RIFKeyedLatency=%.3f ms", inFlight, avgLatencyMs, rifKeyedLatencyMs)
+		loadAvg1, loadAvg5, cpuPercent, memUsedPercent := hostLoadSignals(logger)
+
+		logger.Debug("reporting metrics", slog.Int("in_flight", inFlight), slog.Float64("avg_latency_ms", avgLatencyMs), slog.Float64("rif_keyed_latency_ms", rifKeyedLatencyMs))
 
 		w.Header().Set("Content-Type", "application/json")
 		resp := contracts.ProbeResponse{
 			RequestsInFlight:  int64(inFlight),
 			AverageLatencyMs:  avgLatencyMs,
 			RIFKeyedLatencyMs: rifKeyedLatencyMs,
+			LoadAvg1:          loadAvg1,
+			LoadAvg5:          loadAvg5,
+			CPUPercent:        cpuPercent,
+			MemUsedPercent:    memUsedPercent,
 		}
 		json.NewEncoder(w).Encode(resp)
 	})
 
-	// Heartbeat goroutine to register with proxy
+	// Heartbeat goroutine to register with proxy, over HTTP or gRPC depending
+	// on HEARTBEAT_TRANSPORT.
 	proxyAddr := os.Getenv("PROXY_ADDR")
 	if proxyAddr == "" {
 		proxyAddr = "http://localhost:8080"
 	}
-	go func() {
-		for {
-			reqBody := map[string]string{"url": url}
-			data, err := json.Marshal(reqBody)
-			if err != nil {
-				log.Printf("Heartbeat marshal error: %v", err)
+	if os.Getenv("HEARTBEAT_TRANSPORT") == "grpc" {
+		grpcAddr := os.Getenv("GRPC_ADDR")
+		if grpcAddr == "" {
+			grpcAddr = "localhost:9090"
+		}
+		go runGRPCHeartbeat(grpcAddr, url, heartbeatLogger)
+	} else {
+		go func() {
+			for {
+				reqBody := map[string]string{"url": url}
+				data, err := json.Marshal(reqBody)
+				if err != nil {
+					heartbeatLogger.Error("failed to marshal heartbeat payload", slog.Any("error", err))
+					time.Sleep(5 * time.Second)
+					continue
+				}
+				resp, err := http.Post(proxyAddr+"/heartbeat", "application/json", bytes.NewReader(data))
+				if err != nil {
+					heartbeatLogger.Warn("heartbeat request failed", slog.Any("error", err))
+				} else {
+					resp.Body.Close()
+				}
 				time.Sleep(5 * time.Second)
-				continue
 			}
-			resp, err := http.Post(proxyAddr+"/heartbeat", "application/json", bytes.NewReader(data))
-			if err != nil {
-				log.Printf("Heartbeat failed: %v", err)
-			} else {
-				resp.Body.Close()
-			}
-			time.Sleep(5 * time.Second)
-		}
-	}()
+		}()
+	}
 
 	log.Printf("Backend server listening at %s\n", url)
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// hostLoadSignals samples 1m/5m load average, overall CPU utilization, and
+// memory utilization so the proxy can blend host saturation into its
+// backend-selection score alongside queue-depth signals. Failures are logged
+// and reported as zero rather than failing the /metrics response.
+func hostLoadSignals(logger *slog.Logger) (loadAvg1, loadAvg5, cpuPercent, memUsedPercent float64) {
+	if avg, err := load.Avg(); err != nil {
+		logger.Warn("failed to read load average", slog.Any("error", err))
+	} else {
+		loadAvg1, loadAvg5 = avg.Load1, avg.Load5
+	}
+	if percents, err := cpu.Percent(0, false); err != nil {
+		logger.Warn("failed to read cpu percent", slog.Any("error", err))
+	} else if len(percents) > 0 {
+		cpuPercent = percents[0]
+	}
+	if vm, err := mem.VirtualMemory(); err != nil {
+		logger.Warn("failed to read memory stats", slog.Any("error", err))
+	} else {
+		memUsedPercent = vm.UsedPercent
+	}
+	return
+}
+
+// runGRPCHeartbeat registers selfURL with the proxy at grpcAddr via the
+// Register RPC every 5s, dialing once and reusing the connection.
+func runGRPCHeartbeat(grpcAddr, selfURL string, logger *slog.Logger) {
+	conn, err := grpc.NewClient(grpcAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(probepb.JSONContentSubtype)),
+	)
+	if err != nil {
+		logger.Error("failed to dial proxy", slog.String("addr", grpcAddr), slog.Any("error", err))
+		return
+	}
+	client := probepb.NewProbeClient(conn)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_, err := client.Register(ctx, &probepb.RegisterRequest{Url: selfURL})
+		cancel()
+		if err != nil {
+			logger.Warn("heartbeat request failed", slog.Any("error", err))
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
@@ -1,3 +1,8 @@
+// Package registry is an early prototype of backend registration, kept
+// around for cmd/proxy/test but not wired into cmd/proxy/main.go. The
+// registry the running proxy and loadbalancer.Register factories use is
+// github.com/Pranshu258/OpenPrequal/pkg/registry; start there for anything
+// new.
 package registry
 
 // BackendRegistry defines the interface for backend management
@@ -1,17 +1,32 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"strconv"
 	"time"
 
+	consulapi "github.com/hashicorp/consul/api"
+	"google.golang.org/grpc"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
 	"github.com/Pranshu258/OpenPrequal/pkg/contracts"
+	"github.com/Pranshu258/OpenPrequal/pkg/healthcheck"
 	"github.com/Pranshu258/OpenPrequal/pkg/loadbalancer"
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
 	"github.com/Pranshu258/OpenPrequal/pkg/probe"
+	"github.com/Pranshu258/OpenPrequal/pkg/probe/probepb"
 	"github.com/Pranshu258/OpenPrequal/pkg/registry"
 )
 
@@ -24,23 +39,59 @@ func getLoadBalancerType() string {
 	return "random"
 }
 
+// consistentHashVNodes returns the virtual-node count for
+// ConsistentHashLoadBalancer from CONSISTENT_HASH_VNODES, or 0 to let it
+// apply its own default.
+func consistentHashVNodes() int {
+	if v := os.Getenv("CONSISTENT_HASH_VNODES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			return parsed
+		}
+	}
+	return 0
+}
+
+// createLoadBalancer looks lbType up in the loadbalancer package's strategy
+// registry (pkg/loadbalancer/builtins.go self-registers all of these from
+// init()). Falling back to random on a lookup miss keeps the historical
+// behavior of an unrecognized LOAD_BALANCER_TYPE. Third parties can add a
+// strategy here without forking by calling loadbalancer.Register in their
+// own main.go before this runs, then setting LOAD_BALANCER_TYPE to match.
 func createLoadBalancer(reg registry.BackendRegistry, lbType string) loadbalancer.LoadBalancer {
-	switch lbType {
-	case "random":
+	factory, ok := loadbalancer.Get(lbType)
+	if !ok {
+		observability.ObservePolicyFallback(lbType)
 		return loadbalancer.NewRandomLoadBalancer(reg)
-	case "roundrobin":
-		return loadbalancer.NewRoundRobinLoadBalancer(reg)
-	case "leastrif":
-		return loadbalancer.NewLeastRequestsInFlightLoadBalancer(reg)
-	case "leastlatency":
-		return loadbalancer.NewLeastLatencyLoadBalancer(reg)
-	case "power2_leastrif":
-		return loadbalancer.NewPowerOfTwoLeastRIFLoadBalancer(reg)
-	case "power2_leastlatency":
-		return loadbalancer.NewPowerOfTwoLeastLatencyLoadBalancer(reg)
-	default:
+	}
+	lb, err := factory(reg)
+	if err != nil {
+		log.Printf("createLoadBalancer: %s factory failed: %v, falling back to random", lbType, err)
+		observability.ObservePolicyFallback(lbType)
 		return loadbalancer.NewRandomLoadBalancer(reg)
 	}
+	return lb
+}
+
+// consistentHashSpec derives the ConsistentHashKeyFunc spec for lbType:
+// ip_hash and uri_hash are fixed aliases for "ip" and "path", header_hash
+// hashes a configurable header (CONSISTENT_HASH_HEADER, default
+// X-Session-Id), and plain "consistent_hash" keeps reading the spec
+// straight out of CONSISTENT_HASH_KEY as before.
+func consistentHashSpec(lbType string) string {
+	switch lbType {
+	case "ip_hash":
+		return "ip"
+	case "uri_hash":
+		return "path"
+	case "header_hash":
+		header := os.Getenv("CONSISTENT_HASH_HEADER")
+		if header == "" {
+			header = "X-Session-Id"
+		}
+		return "header:" + header
+	default:
+		return os.Getenv("CONSISTENT_HASH_KEY")
+	}
 }
 
 // getRegistryType returns the registry type from env/config, defaulting to "inmemory"
@@ -73,13 +124,156 @@ func createRegistry(regType string) registry.BackendRegistry {
 	switch regType {
 	case "inmemory":
 		return registry.NewInMemoryBackendRegistry(backends)
-	// case "redis":
-	//     return registry.NewRedisBackendRegistry(...)
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		key := os.Getenv("REDIS_REGISTRY_KEY")
+		if key == "" {
+			key = "openprequal:backends"
+		}
+		reg := registry.NewRedisBackendRegistry(addr, key)
+		for _, url := range backends {
+			reg.RegisterBackend(url)
+		}
+		return reg
+	case "consul", "etcd", "k8s", "dns":
+		return createDiscoveryRegistry(regType, backends)
 	default:
 		return registry.NewInMemoryBackendRegistry(backends)
 	}
 }
 
+// discoveryInterval returns the DISCOVERY_POLL_INTERVAL_MS override, or 0 to
+// let DiscoveryBackendRegistry apply its own default.
+func discoveryInterval() time.Duration {
+	if v := os.Getenv("DISCOVERY_POLL_INTERVAL_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	return 0
+}
+
+// createDiscoveryRegistry wires up a DiscoverySource for regType, starts its
+// polling loop, and composes it with an in-memory registry seeded from
+// BACKEND_URLS so statically-configured backends and dynamically-discovered
+// ones are both routable.
+func createDiscoveryRegistry(regType string, staticBackends []string) registry.BackendRegistry {
+	var source registry.DiscoverySource
+	switch regType {
+	case "consul":
+		source = newConsulDiscoverySource()
+	case "etcd":
+		source = newEtcdDiscoverySource()
+	case "k8s":
+		source = newKubernetesDiscoverySource()
+	case "dns":
+		source = newDNSDiscoverySource()
+	}
+	if source == nil {
+		log.Printf("createDiscoveryRegistry: could not configure %s discovery source, falling back to inmemory", regType)
+		return registry.NewInMemoryBackendRegistry(staticBackends)
+	}
+
+	disc := registry.NewDiscoveryBackendRegistry(source, discoveryInterval())
+	disc.Start(context.Background())
+
+	primary := registry.NewInMemoryBackendRegistry(staticBackends)
+	return registry.NewCompositeBackendRegistry(primary, disc)
+}
+
+// newConsulDiscoverySource builds a ConsulDiscoverySource from
+// CONSUL_ADDR/CONSUL_SERVICE/CONSUL_TAG/CONSUL_SCHEME, defaulting to the
+// local agent on a service named "backend".
+func newConsulDiscoverySource() registry.DiscoverySource {
+	cfg := consulapi.DefaultConfig()
+	if addr := os.Getenv("CONSUL_ADDR"); addr != "" {
+		cfg.Address = addr
+	}
+	service := os.Getenv("CONSUL_SERVICE")
+	if service == "" {
+		service = "backend"
+	}
+	source, err := registry.NewConsulDiscoverySource(cfg, service, os.Getenv("CONSUL_TAG"), os.Getenv("CONSUL_SCHEME"))
+	if err != nil {
+		log.Printf("newConsulDiscoverySource: %v", err)
+		return nil
+	}
+	return source
+}
+
+// newEtcdDiscoverySource builds an EtcdDiscoverySource from
+// ETCD_ENDPOINTS (comma-separated) and ETCD_PREFIX.
+func newEtcdDiscoverySource() registry.DiscoverySource {
+	endpointsEnv := os.Getenv("ETCD_ENDPOINTS")
+	if endpointsEnv == "" {
+		endpointsEnv = "localhost:2379"
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   splitAndTrim(endpointsEnv, ","),
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		log.Printf("newEtcdDiscoverySource: %v", err)
+		return nil
+	}
+	prefix := os.Getenv("ETCD_PREFIX")
+	if prefix == "" {
+		prefix = "/openprequal/backends/"
+	}
+	return registry.NewEtcdDiscoverySource(client, prefix)
+}
+
+// newKubernetesDiscoverySource builds a KubernetesDiscoverySource from
+// K8S_NAMESPACE/K8S_SERVICE/K8S_PORT_NAME/K8S_SCHEME, using in-cluster config
+// when available and falling back to KUBECONFIG otherwise.
+func newKubernetesDiscoverySource() registry.DiscoverySource {
+	restCfg, err := rest.InClusterConfig()
+	if err != nil {
+		kubeconfig := os.Getenv("KUBECONFIG")
+		restCfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			log.Printf("newKubernetesDiscoverySource: %v", err)
+			return nil
+		}
+	}
+	client, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		log.Printf("newKubernetesDiscoverySource: %v", err)
+		return nil
+	}
+	namespace := os.Getenv("K8S_NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+	service := os.Getenv("K8S_SERVICE")
+	if service == "" {
+		service = "backend"
+	}
+	return registry.NewKubernetesDiscoverySource(client, namespace, service, os.Getenv("K8S_PORT_NAME"), os.Getenv("K8S_SCHEME"))
+}
+
+// newDNSDiscoverySource builds a DNSDiscoverySource from
+// DNS_SERVICE/DNS_PROTO/DNS_NAME/DNS_SCHEME.
+func newDNSDiscoverySource() registry.DiscoverySource {
+	name := os.Getenv("DNS_NAME")
+	if name == "" {
+		log.Printf("newDNSDiscoverySource: DNS_NAME is required")
+		return nil
+	}
+	service := os.Getenv("DNS_SERVICE")
+	if service == "" {
+		service = "http"
+	}
+	proto := os.Getenv("DNS_PROTO")
+	if proto == "" {
+		proto = "tcp"
+	}
+	return registry.NewDNSDiscoverySource(nil, service, proto, name, os.Getenv("DNS_SCHEME"))
+}
+
 // splitAndTrim splits a string by sep and trims spaces from each part
 func splitAndTrim(s, sep string) []string {
 	var result []string
@@ -120,7 +314,33 @@ func main() {
 	reg := createRegistry(regType)
 
 	lbType := getLoadBalancerType()
-	lb := createLoadBalancer(reg, lbType)
+	var lb loadbalancer.RequestAwareLoadBalancer
+	// inflightTracker is set when lbType resolves to a LeastInFlightLoadBalancer,
+	// so the handler below can Acquire/Release around each proxied request;
+	// it stays nil (a no-op) for every other strategy.
+	var inflightTracker *loadbalancer.LeastInFlightLoadBalancer
+	switch lbType {
+	case "consistent_hash", "ip_hash", "uri_hash", "header_hash":
+		lb = loadbalancer.NewConsistentHashLoadBalancer(reg, loadbalancer.ConsistentHashKeyFunc(consistentHashSpec(lbType)), consistentHashVNodes())
+	case "rendezvous_hash":
+		lb = loadbalancer.NewRendezvousHashLoadBalancer(reg, loadbalancer.ConsistentHashKeyFunc(os.Getenv("CONSISTENT_HASH_KEY")))
+	default:
+		inner := createLoadBalancer(reg, lbType)
+		inflightTracker, _ = inner.(*loadbalancer.LeastInFlightLoadBalancer)
+		lb = loadbalancer.NewStickyLoadBalancer(inner, reg, loadbalancer.StickyConfigFromEnv())
+	}
+
+	logger := observability.NewLogger("")
+	prober := probe.NewBackendProber(os.Getenv("PROBE_TRANSPORT"), logger.With(slog.String("component", "proxy_prober")))
+
+	// Active health checker: unlike the probe loop above (which only feeds
+	// hot/cold classification), this flips BackendInfo.Healthy once a
+	// backend crosses a consecutive pass/fail threshold, so RoundRobin,
+	// Random, and PowerOfTwoLeastLatency stop routing to it.
+	healthChecker := healthcheck.NewChecker(reg, healthcheck.ConfigFromEnv(), logger.With(slog.String("component", "healthcheck")))
+	healthCtx, cancelHealthCheck := context.WithCancel(context.Background())
+	defer cancelHealthCheck()
+	go healthChecker.Run(healthCtx)
 
 	// Periodically probe backends and update metrics
 	go func() {
@@ -131,29 +351,31 @@ func main() {
 			// ListBackends returns []BackendInfo
 			backends := reg.ListBackends()
 			for _, backend := range backends {
-				result, err := probe.ProbeBackend(backend.URL)
+				result, err := prober.ProbeBackend(backend.URL)
 				if err != nil {
 					log.Printf("Probe failed for %s: %v", backend.URL, err)
 					continue
 				}
-				// Update metrics in registry
-				// Only works for InMemoryBackendRegistry
-				if memReg, ok := reg.(*registry.InMemoryBackendRegistry); ok {
-					if b, exists := memReg.Backends[backend.URL]; exists {
-						b.RequestsInFlight = result.RequestsInFlight
-						b.AverageLatencyMs = result.AverageLatencyMs
-					}
-				}
+				// Update metrics in registry; works for any BackendRegistry implementation
+				reg.UpdateMetrics(backend.URL, result.RequestsInFlight, result.AverageLatencyMs, result.RIFKeyedLatencyMs)
+				observability.ObserveBackendMetrics(backend.URL, float64(result.RequestsInFlight), result.AverageLatencyMs)
 			}
 		}
 	}()
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		backendURL, err := url.Parse(lb.PickBackend())
+		backend := lb.PickBackend(r, w)
+		observability.ObserveBackendPick(backend)
+		observability.ObservePolicySelection(lbType)
+		backendURL, err := url.Parse(backend)
 		if err != nil {
 			http.Error(w, "Bad backend URL", http.StatusInternalServerError)
 			return
 		}
+		if inflightTracker != nil {
+			inflightTracker.Acquire(backend)
+			defer inflightTracker.Release(backend)
+		}
 		proxy := httputil.NewSingleHostReverseProxy(backendURL)
 		proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, e error) {
 			log.Printf("Proxy error: %v", e)
@@ -162,6 +384,15 @@ func main() {
 		proxy.ServeHTTP(w, r)
 	})
 
+	http.Handle("/metrics", observability.Handler())
+
+	// Reports the active health checker's current view of every backend it
+	// has checked: status, latest latency sample, and last-check time.
+	http.HandleFunc("/health/backends", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(healthChecker.Status())
+	})
+
 	// Heartbeat endpoint for backend registration
 	http.HandleFunc("/heartbeat", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -174,14 +405,53 @@ func main() {
 			http.Error(w, "Invalid or missing url in JSON body", http.StatusBadRequest)
 			return
 		}
-		reg.RegisterBackend(reqData.URL)
+		if reqData.Weight > 0 {
+			reg.RegisterBackendWithWeight(reqData.URL, reqData.Weight)
+		} else {
+			reg.RegisterBackend(reqData.URL)
+		}
 		log.Printf("Registered backend: %s", reqData.URL)
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
 
+	// gRPC heartbeat endpoint, mirroring /heartbeat for backends started with
+	// HEARTBEAT_TRANSPORT=grpc.
+	grpcAddr := os.Getenv("GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = ":9090"
+	}
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", grpcAddr, err)
+	}
+	grpcServer := grpc.NewServer()
+	probepb.RegisterProbeServer(grpcServer, &registerServer{reg: reg, logger: logger})
+	go func() {
+		log.Printf("gRPC heartbeat endpoint listening on %s", grpcAddr)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+
 	log.Println("Load balancer listening on :8080 (algorithm:", lbType, ", registry:", regType, ")")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// registerServer implements probepb.ProbeServer's Register RPC so backends
+// started with HEARTBEAT_TRANSPORT=grpc can join the registry without a
+// JSON POST. GetMetrics/WatchMetrics are unimplemented on the proxy side;
+// only backends serve those.
+type registerServer struct {
+	probepb.UnimplementedProbeServer
+	reg    registry.BackendRegistry
+	logger *slog.Logger
+}
+
+func (s *registerServer) Register(ctx context.Context, req *probepb.RegisterRequest) (*probepb.RegisterResponse, error) {
+	s.reg.RegisterBackend(req.Url)
+	s.logger.Info("registered backend via gRPC", slog.String("url", req.Url))
+	return &probepb.RegisterResponse{Ok: true}, nil
+}
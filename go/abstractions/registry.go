@@ -2,6 +2,7 @@ package abstractions
 
 import (
 	"openprequal/contracts"
+	"openprequal/events"
 	"sync"
 )
 
@@ -10,6 +11,10 @@ type Registry interface {
 	Register(b contracts.Backend)
 	Unregister(b contracts.Backend)
 	ListBackends() []contracts.Backend
+	// SetHealth flips the Health flag of the backend matching url, so health
+	// checks and load balancers (which filter on Backend.Health) stay in
+	// sync. It is a no-op if url is not registered.
+	SetHealth(url string, healthy bool)
 }
 
 // NewInMemoryRegistry creates a simple in-memory registry.
@@ -21,19 +26,30 @@ func NewInMemoryRegistry() *InMemoryRegistry {
 type InMemoryRegistry struct {
 	mu       sync.Mutex
 	backends []contracts.Backend
+	bus      *events.Bus
+}
+
+// SetBus makes Register/Unregister publish BackendRegistered/
+// BackendUnregistered events, mirroring core.BackendRegistry; nil disables
+// publishing (the default).
+func (r *InMemoryRegistry) SetBus(bus *events.Bus) {
+	r.mu.Lock()
+	r.bus = bus
+	r.mu.Unlock()
 }
 
 // Register adds a backend to the registry.
 func (r *InMemoryRegistry) Register(b contracts.Backend) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	r.backends = append(r.backends, b)
+	bus := r.bus
+	r.mu.Unlock()
+	bus.Publish(events.Event{Kind: events.BackendRegistered, Backend: b.URL})
 }
 
 // Unregister removes backends that match url and port.
 func (r *InMemoryRegistry) Unregister(b contracts.Backend) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	out := r.backends[:0]
 	for _, be := range r.backends {
 		if be.Equal(&b) {
@@ -42,6 +58,9 @@ func (r *InMemoryRegistry) Unregister(b contracts.Backend) {
 		out = append(out, be)
 	}
 	r.backends = out
+	bus := r.bus
+	r.mu.Unlock()
+	bus.Publish(events.Event{Kind: events.BackendUnregistered, Backend: b.URL})
 }
 
 // ListBackends returns a copy of backends.
@@ -53,5 +72,16 @@ func (r *InMemoryRegistry) ListBackends() []contracts.Backend {
 	return copyOut
 }
 
+// SetHealth flips the Health flag of the backend matching url.
+func (r *InMemoryRegistry) SetHealth(url string, healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.backends {
+		if r.backends[i].URL == url {
+			r.backends[i].Health = healthy
+		}
+	}
+}
+
 // ModelDump returns a map representation of the backend (rough parity with Python model_dump())
 // ModelDump now lives on contracts.Backend
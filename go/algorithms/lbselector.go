@@ -0,0 +1,35 @@
+package algorithms
+
+import "context"
+
+// LBSelector extends SelectionPolicy with a managed lifecycle and a
+// feedback surface, for policies (currently just Prequal) that run
+// background work and can use post-request signal to improve future picks.
+// NewPrequal already calls Start with a background context, so main.go does
+// not call Start; it calls Close at shutdown, and CancelWorkload on client
+// disconnect instead of letting an abandoned probe round run to
+// completion for nothing. A SelectionPolicy that has no background work or
+// feedback to accept simply doesn't implement LBSelector; callers type-assert
+// for it rather than requiring every policy to satisfy it.
+type LBSelector interface {
+	SelectionPolicy
+
+	// Start begins any background work the policy needs (e.g. Prequal's
+	// probe scheduler) and returns once it's running. Cancelling ctx stops
+	// that background work; it does not change the lifecycle state, so
+	// Close is still needed to reach Stopped.
+	Start(ctx context.Context) error
+	// Close stops background work started by Start. Safe to call even if
+	// Start was never called.
+	Close() error
+	// CancelWorkload abandons any in-flight work this policy is doing on
+	// backend's behalf on behalf of a specific request (e.g. a scheduled
+	// probe round), typically because the client that would have used the
+	// result disconnected.
+	CancelWorkload(backend string)
+	// UpdateCostMetrics feeds a completed request's observed cost (e.g.
+	// latency in milliseconds) for backend back into the policy, so
+	// subsequent Select calls can weigh it without waiting for the next
+	// probe round.
+	UpdateCostMetrics(backend string, cost float64)
+}
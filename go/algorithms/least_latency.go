@@ -1,6 +1,8 @@
 package algorithms
 
 import (
+	"net/http"
+
 	"openprequal/abstractions"
 	"openprequal/contracts"
 )
@@ -22,3 +24,9 @@ func (l *LeastLatencyLB) Next() *contracts.Backend {
 	}
 	return best
 }
+
+// Select implements SelectionPolicy. LeastLatencyLB already tracks its own state
+// against the registry it was built with, so req and backends are unused.
+func (l *LeastLatencyLB) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return l.Next()
+}
@@ -2,6 +2,8 @@ package algorithms
 
 import (
 	"math/rand"
+	"net/http"
+
 	"openprequal/abstractions"
 	"openprequal/contracts"
 )
@@ -32,3 +34,9 @@ func (l *LeastLatencyP2) Next() *contracts.Backend {
 	}
 	return &b2
 }
+
+// Select implements SelectionPolicy. LeastLatencyP2 already tracks its own state
+// against the registry it was built with, so req and backends are unused.
+func (l *LeastLatencyP2) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return l.Next()
+}
@@ -1,13 +1,36 @@
 package algorithms
 
 import (
+	"net/http"
+
 	"openprequal/abstractions"
 	"openprequal/contracts"
+	"openprequal/core"
 )
 
-type LeastRIF struct{ reg abstractions.Registry }
+// LeastRIF picks the healthy backend with the fewest requests in flight. If
+// inflight is non-nil, it consults InflightTracker's live, proxy-side count
+// instead of Backend.InFlightRequests, which only updates on that backend's
+// next heartbeat and so can be stale by seconds.
+type LeastRIF struct {
+	reg      abstractions.Registry
+	inflight *core.InflightTracker
+}
+
+// NewLeastRIF creates a LeastRIF against r. inflight may be nil, in which
+// case selection falls back to each Backend's last-reported
+// InFlightRequests.
+func NewLeastRIF(r abstractions.Registry, inflight *core.InflightTracker) *LeastRIF {
+	return &LeastRIF{reg: r, inflight: inflight}
+}
+
+func (l *LeastRIF) rif(b *contracts.Backend) float64 {
+	if l.inflight != nil {
+		return float64(l.inflight.InFlight(b.URL))
+	}
+	return b.InFlightRequests
+}
 
-func NewLeastRIF(r abstractions.Registry) *LeastRIF { return &LeastRIF{reg: r} }
 func (l *LeastRIF) Next() *contracts.Backend {
 	list := l.reg.ListBackends()
 	var best *contracts.Backend
@@ -15,10 +38,16 @@ func (l *LeastRIF) Next() *contracts.Backend {
 		if !b.Health {
 			continue
 		}
-		if best == nil || b.InFlightRequests < best.InFlightRequests {
+		if best == nil || l.rif(&b) < l.rif(best) {
 			bb := b
 			best = &bb
 		}
 	}
 	return best
 }
+
+// Select implements SelectionPolicy. LeastRIF already tracks its own state
+// against the registry it was built with, so req and backends are unused.
+func (l *LeastRIF) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return l.Next()
+}
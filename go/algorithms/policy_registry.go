@@ -0,0 +1,109 @@
+package algorithms
+
+import (
+	"fmt"
+	"sync"
+
+	"openprequal/abstractions"
+	"openprequal/core"
+)
+
+// PolicyContext bundles the collaborators a Factory may need to build its
+// SelectionPolicy. A factory reads only the fields its policy actually
+// depends on (e.g. weighted_round_robin needs none of them); the others are
+// left as the zero value.
+type PolicyContext struct {
+	Registry          abstractions.Registry
+	ProbePool         *core.ProbePool
+	ProbeQueue        *core.ProbeTaskQueue
+	Inflight          *core.InflightTracker
+	HeaderName        string
+	CookieName        string
+	FirstAvailableRIF float64
+}
+
+// Factory builds a SelectionPolicy from ctx. Factories are registered under
+// a short name (e.g. "round_robin") so a policy can be selected by config
+// string without the caller importing every implementation, similar in
+// spirit to Caddy's selectionpolicies module registry.
+type Factory func(ctx PolicyContext) (SelectionPolicy, error)
+
+var (
+	policiesMu sync.RWMutex
+	policies   = map[string]Factory{}
+)
+
+// Register adds factory under name, so it becomes selectable via Get. It
+// panics on a nil factory or a duplicate name, since both indicate a
+// programming error to catch at init time rather than a runtime condition
+// to recover from.
+func Register(name string, factory Factory) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	if factory == nil {
+		panic("algorithms: Register factory is nil")
+	}
+	if _, dup := policies[name]; dup {
+		panic("algorithms: Register called twice for policy " + name)
+	}
+	policies[name] = factory
+}
+
+// Get looks up the factory registered under name.
+func Get(name string) (Factory, bool) {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	factory, ok := policies[name]
+	return factory, ok
+}
+
+func init() {
+	prequalFactory := func(ctx PolicyContext) (SelectionPolicy, error) {
+		if ctx.ProbePool == nil || ctx.ProbeQueue == nil {
+			return nil, fmt.Errorf("algorithms: prequal requires a ProbePool and ProbeTaskQueue")
+		}
+		return NewPrequal(ctx.Registry, ctx.ProbePool, ctx.ProbeQueue), nil
+	}
+	Register("prequal", prequalFactory)
+	Register("default", prequalFactory)
+
+	Register("round_robin", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewRoundRobin(ctx.Registry), nil
+	})
+	Register("random", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewRandomLB(ctx.Registry), nil
+	})
+	Register("least_latency", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewLeastLatencyLB(ctx.Registry), nil
+	})
+	Register("least_latency_p2", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewLeastLatencyP2(ctx.Registry), nil
+	})
+	Register("least_rif", func(ctx PolicyContext) (SelectionPolicy, error) {
+		if ctx.Inflight == nil {
+			return nil, fmt.Errorf("algorithms: least_rif requires an InflightTracker")
+		}
+		return NewLeastRIF(ctx.Registry, ctx.Inflight), nil
+	})
+	Register("least_rif_p2", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewLeastRIFP2(ctx.Registry), nil
+	})
+	Register("weighted_round_robin", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewWeightedRoundRobin(), nil
+	})
+	Register("header_hash", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewHeaderHash(ctx.HeaderName), nil
+	})
+	Register("cookie_hash", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewCookieHash(ctx.CookieName), nil
+	})
+	Register("ip_hash", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewIPHash(), nil
+	})
+	Register("uri_hash", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewURIHash(), nil
+	})
+	Register("first_available", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewFirstAvailable(ctx.FirstAvailableRIF), nil
+	})
+}
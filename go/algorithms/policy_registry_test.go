@@ -0,0 +1,79 @@
+package algorithms
+
+import (
+	"testing"
+
+	"openprequal/abstractions"
+	"openprequal/core"
+)
+
+func TestGetReturnsBuiltinPolicies(t *testing.T) {
+	names := []string{
+		"prequal", "default", "round_robin", "random", "least_latency",
+		"least_latency_p2", "least_rif", "least_rif_p2", "weighted_round_robin",
+		"header_hash", "cookie_hash", "ip_hash", "uri_hash", "first_available",
+	}
+	ctx := PolicyContext{
+		Registry:   abstractions.NewInMemoryRegistry(),
+		ProbePool:  core.NewProbePool(),
+		ProbeQueue: core.NewProbeTaskQueue(),
+		Inflight:   core.NewInflightTracker(),
+	}
+	for _, name := range names {
+		factory, ok := Get(name)
+		if !ok {
+			t.Errorf("Get(%q) not found", name)
+			continue
+		}
+		policy, err := factory(ctx)
+		if err != nil {
+			t.Errorf("factory(%q) returned error: %v", name, err)
+			continue
+		}
+		if policy == nil {
+			t.Errorf("factory(%q) returned a nil SelectionPolicy", name)
+		}
+		if p, ok := policy.(*Prequal); ok {
+			p.Close()
+		}
+	}
+}
+
+func TestGetReturnsFalseForUnknownPolicy(t *testing.T) {
+	if _, ok := Get("not_a_real_policy"); ok {
+		t.Fatalf("Get(\"not_a_real_policy\") = _, true, want false")
+	}
+}
+
+func TestLeastRIFFactoryRequiresInflightTracker(t *testing.T) {
+	factory, ok := Get("least_rif")
+	if !ok {
+		t.Fatalf("Get(\"least_rif\") not found")
+	}
+	ctx := PolicyContext{Registry: abstractions.NewInMemoryRegistry()}
+	if _, err := factory(ctx); err == nil {
+		t.Fatalf("expected an error when Inflight is nil")
+	}
+}
+
+func TestPrequalFactoryRequiresProbeCollaborators(t *testing.T) {
+	factory, ok := Get("prequal")
+	if !ok {
+		t.Fatalf("Get(\"prequal\") not found")
+	}
+	ctx := PolicyContext{Registry: abstractions.NewInMemoryRegistry()}
+	if _, err := factory(ctx); err == nil {
+		t.Fatalf("expected an error when ProbePool/ProbeQueue are nil")
+	}
+}
+
+func TestRegisterPanicsOnDuplicateName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected Register to panic on a duplicate name")
+		}
+	}()
+	Register("round_robin", func(ctx PolicyContext) (SelectionPolicy, error) {
+		return NewRoundRobin(ctx.Registry), nil
+	})
+}
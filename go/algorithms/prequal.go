@@ -1,71 +1,132 @@
 package algorithms
 
 import (
+	"context"
 	"math"
 	"math/rand"
-	"sort"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"openprequal/abstractions"
 	"openprequal/contracts"
 	"openprequal/core"
+	"openprequal/events"
+	"openprequal/metrics"
 )
 
 // Prequal implements a more complete PrequalLoadBalancer port.
 type Prequal struct {
+	core.Lifecycle
+
 	reg  abstractions.Registry
 	pool *core.ProbePool
 	tq   *core.ProbeTaskQueue
 
+	classifier RIFClassifier
+
 	mu                     sync.Mutex
 	probeHistory           map[string]struct{}
 	requestTimestamps      []time.Time
 	lastProbeTime          map[string]time.Time
-	rifMedianCache         map[string]float64
-	rifLastInfoCount       map[string]int
-	rifLastInfoLast        map[string]float64
 	latencyCache           map[string]float64
 	latencyCacheTime       map[string]time.Time
 	healthyBackendsCache   []contracts.Backend
 	healthyBackendsCacheAt time.Time
 	cacheTimeout           time.Duration
 
+	paused int32 // 1 while Paused; checked by probeSchedulerLoop between ticks
+	wg     sync.WaitGroup
 	stopCh chan struct{}
+	runCtx context.Context // bounds probeSchedulerLoop alongside stopCh; set by Start
+
+	bus *events.Bus
 }
 
+// SetBus makes selectBackend publish a PolicySelected event per pick; nil
+// disables publishing (the default).
+func (p *Prequal) SetBus(bus *events.Bus) {
+	p.bus = bus
+}
+
+// NewPrequal builds a Prequal that classifies backends hot/cold with
+// MedianWindowClassifier, matching its original behavior. Use
+// NewPrequalWithClassifier to plug in EWMAClassifier, P2QuantileClassifier,
+// or a custom RIFClassifier instead.
 func NewPrequal(reg abstractions.Registry, pool *core.ProbePool, tq *core.ProbeTaskQueue) *Prequal {
+	return NewPrequalWithClassifier(reg, pool, tq, NewMedianWindowClassifier())
+}
+
+// NewPrequalWithClassifier builds a Prequal that uses classifier to decide
+// whether a backend is hot or cold in classifyBackends.
+func NewPrequalWithClassifier(reg abstractions.Registry, pool *core.ProbePool, tq *core.ProbeTaskQueue, classifier RIFClassifier) *Prequal {
 	p := &Prequal{
 		reg:              reg,
 		pool:             pool,
 		tq:               tq,
+		classifier:       classifier,
 		probeHistory:     make(map[string]struct{}),
 		lastProbeTime:    make(map[string]time.Time),
-		rifMedianCache:   make(map[string]float64),
-		rifLastInfoCount: make(map[string]int),
-		rifLastInfoLast:  make(map[string]float64),
 		latencyCache:     make(map[string]float64),
 		latencyCacheTime: make(map[string]time.Time),
 		cacheTimeout:     5 * time.Millisecond,
-		stopCh:           make(chan struct{}),
 	}
-	go p.probeSchedulerLoop()
+	p.Start(context.Background())
 	return p
 }
 
-func median(xs []float64) float64 {
-	if len(xs) == 0 {
-		return 0
+// Start begins the background probe scheduler, transitioning from New or
+// Stopped into Running. It is called automatically by NewPrequal with a
+// background context; callers that want the scheduler to stop when a
+// caller-owned context is cancelled (in addition to Close/Stop) can call it
+// again after a Stop with their own ctx.
+func (p *Prequal) Start(ctx context.Context) error {
+	if err := p.Transition(core.StateRunning, core.StateNew, core.StateStopped); err != nil {
+		return err
 	}
-	sort.Float64s(xs)
-	n := len(xs)
-	if n%2 == 1 {
-		return xs[n/2]
+	p.runCtx = ctx
+	p.stopCh = make(chan struct{})
+	p.wg.Add(1)
+	go p.probeSchedulerLoop()
+	return nil
+}
+
+// Pause stops scheduling new probe rounds without discarding the pool or
+// classification caches, so Next still selects among the last samples seen.
+func (p *Prequal) Pause() error {
+	if err := p.Transition(core.StatePaused, core.StateRunning); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.paused, 1)
+	return nil
+}
+
+// Resume undoes Pause, letting probeSchedulerLoop resume scheduling probes.
+func (p *Prequal) Resume() error {
+	if err := p.Transition(core.StateRunning, core.StatePaused); err != nil {
+		return err
 	}
-	return (xs[n/2-1] + xs[n/2]) / 2
+	atomic.StoreInt32(&p.paused, 0)
+	return nil
+}
+
+// Drain stops scheduling new probes (like Pause) and then blocks until the
+// probe task queue empties or deadline elapses, so in-flight probe rounds
+// finish instead of being abandoned mid-flight.
+func (p *Prequal) Drain(deadline time.Duration) error {
+	if err := p.Transition(core.StateDraining, core.StateRunning, core.StatePaused); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.paused, 1)
+	core.WaitFor(deadline, 50*time.Millisecond, func() bool { return p.tq.Size() == 0 })
+	return nil
 }
 
-// classifyBackends returns cold, hot lists and rifs map keyed by backend URL.
+// classifyBackends returns cold, hot lists and rifs map keyed by backend URL,
+// using p.classifier to decide hot vs cold for any backend with at least one
+// recorded RIF reading; a backend with none is always cold (nothing to
+// classify from yet).
 func (p *Prequal) classifyBackends(backends []contracts.Backend) (cold []contracts.Backend, hot []contracts.Backend, rifsMap map[string][]float64) {
 	rifsMap = make(map[string][]float64)
 	// fetch RIFs
@@ -79,24 +140,10 @@ func (p *Prequal) classifyBackends(backends []contracts.Backend) (cold []contrac
 			cold = append(cold, backend)
 			continue
 		}
-		count := len(rifs)
-		last := rifs[count-1]
-
-		// use cached median when possible
-		if p.rifLastInfoCount[backend.URL] == count && p.rifLastInfoLast[backend.URL] == last {
-			// cached
+		if p.classifier.IsHot(backend.URL, rifs) {
+			hot = append(hot, backend)
 		} else {
-			med := median(append([]float64(nil), rifs...))
-			p.rifMedianCache[backend.URL] = med
-			p.rifLastInfoCount[backend.URL] = count
-			p.rifLastInfoLast[backend.URL] = last
-		}
-
-		med := p.rifMedianCache[backend.URL]
-		if last < med {
 			cold = append(cold, backend)
-		} else {
-			hot = append(hot, backend)
 		}
 	}
 	return
@@ -145,6 +192,8 @@ func (p *Prequal) selectBackend(cold, hot []contracts.Backend, rifsMap map[strin
 		if len(candidates) > 0 {
 			chosenIdx := candidates[rand.Intn(len(candidates))]
 			bb := cold[chosenIdx]
+			metrics.ObserveHotCold(bb.URL, "cold")
+			p.bus.Publish(events.Event{Kind: events.PolicySelected, Backend: bb.URL, Data: map[string]interface{}{"classification": "cold"}})
 			return &bb
 		}
 	}
@@ -185,6 +234,8 @@ func (p *Prequal) selectBackend(cold, hot []contracts.Backend, rifsMap map[strin
 		if len(candidates) > 0 {
 			chosenIdx := candidates[rand.Intn(len(candidates))]
 			bb := hot[chosenIdx]
+			metrics.ObserveHotCold(bb.URL, "hot")
+			p.bus.Publish(events.Event{Kind: events.PolicySelected, Backend: bb.URL, Data: map[string]interface{}{"classification": "hot"}})
 			return &bb
 		}
 	}
@@ -268,17 +319,22 @@ func (p *Prequal) scheduleProbeTasks(healthyBackends []contracts.Backend) {
 	p.mu.Unlock()
 
 	// schedule tasks after unlocking
+	metrics.ObserveProbesScheduled(len(tasksToSchedule))
 	for _, id := range tasksToSchedule {
 		go p.tq.AddTask(id)
 	}
 }
 
 func (p *Prequal) probeSchedulerLoop() {
+	defer p.wg.Done()
 	ticker := time.NewTicker(20 * time.Millisecond)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
+			if atomic.LoadInt32(&p.paused) == 1 {
+				continue
+			}
 			all := p.reg.ListBackends()
 			healthy := make([]contracts.Backend, 0)
 			for _, b := range all {
@@ -291,6 +347,8 @@ func (p *Prequal) probeSchedulerLoop() {
 			}
 		case <-p.stopCh:
 			return
+		case <-p.runCtx.Done():
+			return
 		}
 	}
 }
@@ -330,5 +388,47 @@ func (p *Prequal) Next() *contracts.Backend {
 	return &healthy[rand.Intn(len(healthy))]
 }
 
-// Stop stops the background scheduler.
-func (p *Prequal) Stop() { close(p.stopCh) }
+// Stop halts the background scheduler for good, waiting for
+// probeSchedulerLoop to actually exit before returning so a subsequent
+// Start cannot race it over stopCh/runCtx. Call Start to begin a fresh one
+// afterward.
+func (p *Prequal) Stop() error {
+	if err := p.Transition(core.StateStopped, core.StateRunning, core.StatePaused, core.StateDraining); err != nil {
+		return err
+	}
+	close(p.stopCh)
+	p.wg.Wait()
+	return nil
+}
+
+// Close implements LBSelector, stopping the probe scheduler. It's an alias
+// for Stop so Prequal can be driven through main.go's generic Start/Close
+// lifecycle without main.go needing to know it's Prequal specifically.
+func (p *Prequal) Close() error {
+	return p.Stop()
+}
+
+// CancelWorkload implements LBSelector, abandoning a probe round scheduled
+// for backend if one hasn't been picked up yet, e.g. because the request
+// that would have used its result disconnected.
+func (p *Prequal) CancelWorkload(backend string) {
+	p.tq.RemoveTask(backend)
+}
+
+// UpdateCostMetrics implements LBSelector, feeding an observed request
+// latency for backend directly into the cold-path latency cache so the next
+// Select call can weigh it without waiting for the next probe round.
+func (p *Prequal) UpdateCostMetrics(backend string, cost float64) {
+	p.mu.Lock()
+	p.latencyCache[backend] = cost
+	p.latencyCacheTime[backend] = time.Now()
+	p.mu.Unlock()
+}
+
+// Select implements SelectionPolicy, making Prequal's probe-driven picker
+// usable anywhere a SelectionPolicy is expected. req and backends are
+// unused: Prequal tracks its own healthy-backend and probe state against
+// the registry it was built with.
+func (p *Prequal) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return p.Next()
+}
@@ -1,12 +1,16 @@
 package algorithms
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"openprequal/abstractions"
 	"openprequal/contracts"
 	"openprequal/core"
+	"openprequal/metrics"
 )
 
 // helper to register backends
@@ -97,6 +101,8 @@ func TestScheduleProbeTasksForcesProbes(t *testing.T) {
 	p := NewPrequal(reg, pool, tq)
 	defer p.Stop()
 
+	before := testutil.ToFloat64(metrics.ProbesScheduledTotal)
+
 	// ensure lastProbeTime is zero so forced probes will be scheduled
 	// call scheduleProbeTasks directly
 	p.scheduleProbeTasks(healthy)
@@ -107,4 +113,104 @@ func TestScheduleProbeTasksForcesProbes(t *testing.T) {
 	if tq.Size() == 0 {
 		t.Fatalf("expected probe tasks to be scheduled, queue size 0")
 	}
+	if got := testutil.ToFloat64(metrics.ProbesScheduledTotal) - before; got < float64(len(urls)) {
+		t.Fatalf("expected probes_scheduled_total to increase by at least %d, got %v", len(urls), got)
+	}
+}
+
+func TestPrequalImplementsLBSelector(t *testing.T) {
+	reg := abstractions.NewInMemoryRegistry()
+	pool := core.NewProbePool()
+	tq := core.NewProbeTaskQueue()
+
+	p := NewPrequal(reg, pool, tq)
+	defer p.Close()
+
+	var _ LBSelector = p
+}
+
+func TestCancelWorkloadRemovesQueuedProbe(t *testing.T) {
+	reg := abstractions.NewInMemoryRegistry()
+	pool := core.NewProbePool()
+	tq := core.NewProbeTaskQueue()
+	registerBackends(reg, []string{"c1"})
+
+	p := NewPrequal(reg, pool, tq)
+	defer p.Close()
+
+	tq.AddTask("c1")
+	if tq.Size() != 1 {
+		t.Fatalf("expected the probe task to be queued, size = %d", tq.Size())
+	}
+
+	p.CancelWorkload("c1")
+	if tq.Size() != 0 {
+		t.Fatalf("expected CancelWorkload to remove the queued probe, size = %d", tq.Size())
+	}
+}
+
+func TestUpdateCostMetricsSteersColdSelection(t *testing.T) {
+	reg := abstractions.NewInMemoryRegistry()
+	pool := core.NewProbePool()
+	tq := core.NewProbeTaskQueue()
+
+	urls := []string{"u1", "u2"}
+	registerBackends(reg, urls)
+	// RIF history with last < median classifies both backends as cold, with
+	// the same probed latency (50ms) so the pick is a tie until
+	// UpdateCostMetrics reports different costs.
+	for _, u := range urls {
+		pool.AddProbe(u, 50.0, 100.0)
+		pool.AddProbe(u, 50.0, 100.0)
+		pool.AddProbe(u, 50.0, 1.0)
+	}
+
+	p := NewPrequal(reg, pool, tq)
+	defer p.Close()
+	// Keep the injected cost metrics from being raced out by a fresh
+	// GetCurrentLatency refresh before Next reads them back.
+	p.cacheTimeout = time.Hour
+
+	p.UpdateCostMetrics("u1", 1.0)
+	p.UpdateCostMetrics("u2", 100.0)
+
+	chosen := p.Next()
+	if chosen == nil {
+		t.Fatalf("expected a backend, got nil")
+	}
+	if chosen.URL != "u1" {
+		t.Fatalf("expected u1 (lower reported cost), got %s", chosen.URL)
+	}
+}
+
+func TestStartRespectsContextCancellation(t *testing.T) {
+	reg := abstractions.NewInMemoryRegistry()
+	pool := core.NewProbePool()
+	tq := core.NewProbeTaskQueue()
+	registerBackends(reg, []string{"s1"})
+
+	p := NewPrequal(reg, pool, tq)
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start(ctx) error = %v", err)
+	}
+	cancel()
+
+	// Give probeSchedulerLoop a tick to observe ctx.Done and exit on its
+	// own, then drain whatever it queued before stopping.
+	time.Sleep(50 * time.Millisecond)
+	for tq.GetTask() != nil {
+	}
+
+	// A scheduler loop that kept running after ctx cancellation would
+	// eventually re-queue the forced probe; one that exited won't.
+	time.Sleep(50 * time.Millisecond)
+	if tq.Size() != 0 {
+		t.Fatalf("expected no new probes queued after ctx cancellation, queue size = %d", tq.Size())
+	}
+	_ = p.Close()
 }
@@ -2,6 +2,8 @@ package algorithms
 
 import (
 	"math/rand"
+	"net/http"
+
 	"openprequal/abstractions"
 	"openprequal/contracts"
 )
@@ -23,3 +25,9 @@ func (r *RandomLB) Next() *contracts.Backend {
 	i := rand.Intn(len(healthy))
 	return &healthy[i]
 }
+
+// Select implements SelectionPolicy. RandomLB already tracks its own state
+// against the registry it was built with, so req and backends are unused.
+func (r *RandomLB) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return r.Next()
+}
@@ -0,0 +1,276 @@
+package algorithms
+
+import (
+	"sort"
+	"sync"
+)
+
+// RIFClassifier decides whether a backend's requests-in-flight history makes
+// it "hot" (loaded enough that Prequal should pick among hot backends by
+// current RIF) or "cold" (idle enough to pick by probed latency instead).
+// IsHot is called once per classifyBackends pass with backend's full RIF
+// history recorded so far, oldest first; it is never called with an empty
+// history. Implementations that only care about the latest sample (EWMA,
+// P2Quantile) track their own last-seen history length so an unchanged
+// reading between probe rounds doesn't get folded into their streaming
+// state twice.
+type RIFClassifier interface {
+	IsHot(backend string, history []float64) bool
+}
+
+// MedianWindowClassifier is Prequal's original classifier: a backend is hot
+// if its latest RIF reading is at least the median of its whole recorded
+// history. The per-backend median is recomputed only when the history's
+// length or latest sample changed, since sorting the full window is O(n log n) and
+// classifyBackends runs on every selection.
+type MedianWindowClassifier struct {
+	mu         sync.Mutex
+	medianOf   map[string]float64
+	lastLen    map[string]int
+	lastSample map[string]float64
+}
+
+// NewMedianWindowClassifier builds a MedianWindowClassifier with empty
+// per-backend state.
+func NewMedianWindowClassifier() *MedianWindowClassifier {
+	return &MedianWindowClassifier{
+		medianOf:   make(map[string]float64),
+		lastLen:    make(map[string]int),
+		lastSample: make(map[string]float64),
+	}
+}
+
+func (c *MedianWindowClassifier) IsHot(backend string, history []float64) bool {
+	last := history[len(history)-1]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastLen[backend] != len(history) || c.lastSample[backend] != last {
+		c.medianOf[backend] = median(append([]float64(nil), history...))
+		c.lastLen[backend] = len(history)
+		c.lastSample[backend] = last
+	}
+	return last >= c.medianOf[backend]
+}
+
+// EWMAClassifier classifies a backend hot once its latest RIF reading
+// exceeds Alpha times an exponentially-weighted moving average of its past
+// readings, giving O(1) memory per backend instead of MedianWindowClassifier's
+// growing history window.
+type EWMAClassifier struct {
+	// Alpha is the threshold multiplier: hot when last > Alpha*ewma.
+	// Defaults to 1.0 (hot means "above its own recent average").
+	Alpha float64
+	// Smoothing weights each new sample against the running average
+	// (0 < Smoothing <= 1; higher reacts faster to recent samples).
+	// Defaults to 0.2.
+	Smoothing float64
+
+	mu      sync.Mutex
+	ewma    map[string]float64
+	lastLen map[string]int
+}
+
+// NewEWMAClassifier builds an EWMAClassifier. alpha <= 0 defaults to 1.0;
+// smoothing <= 0 or > 1 defaults to 0.2.
+func NewEWMAClassifier(alpha, smoothing float64) *EWMAClassifier {
+	if alpha <= 0 {
+		alpha = 1.0
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+	return &EWMAClassifier{
+		Alpha:     alpha,
+		Smoothing: smoothing,
+		ewma:      make(map[string]float64),
+		lastLen:   make(map[string]int),
+	}
+}
+
+func (c *EWMAClassifier) IsHot(backend string, history []float64) bool {
+	last := history[len(history)-1]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prevEWMA, seen := c.ewma[backend]
+	if !seen {
+		// Bootstrap directly to the first sample, same as EWMADecay in
+		// pkg/loadbalancer does for a fresh backend.
+		c.ewma[backend] = last
+		c.lastLen[backend] = len(history)
+		return false
+	}
+	if c.lastLen[backend] != len(history) {
+		c.ewma[backend] = c.Smoothing*last + (1-c.Smoothing)*prevEWMA
+		c.lastLen[backend] = len(history)
+	}
+	return last > c.Alpha*c.ewma[backend]
+}
+
+// P2QuantileClassifier classifies a backend hot once its latest RIF reading
+// reaches a streaming estimate of its own Quantile, maintained in O(1)
+// memory per backend via the P² algorithm (Jain & Chlamtac) instead of
+// MedianWindowClassifier's growing history window.
+type P2QuantileClassifier struct {
+	// Quantile is the target quantile in (0, 1), e.g. 0.75 for the 75th
+	// percentile. Defaults to 0.75.
+	Quantile float64
+
+	mu      sync.Mutex
+	est     map[string]*p2Quantile
+	lastLen map[string]int
+}
+
+// NewP2QuantileClassifier builds a P2QuantileClassifier. quantile <= 0 or
+// >= 1 defaults to 0.75.
+func NewP2QuantileClassifier(quantile float64) *P2QuantileClassifier {
+	if quantile <= 0 || quantile >= 1 {
+		quantile = 0.75
+	}
+	return &P2QuantileClassifier{
+		Quantile: quantile,
+		est:      make(map[string]*p2Quantile),
+		lastLen:  make(map[string]int),
+	}
+}
+
+func (c *P2QuantileClassifier) IsHot(backend string, history []float64) bool {
+	last := history[len(history)-1]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.est[backend]
+	if !ok {
+		e = newP2Quantile(c.Quantile)
+		c.est[backend] = e
+	}
+	if c.lastLen[backend] != len(history) {
+		e.Add(last)
+		c.lastLen[backend] = len(history)
+	}
+	v, ready := e.Value()
+	if !ready {
+		return false
+	}
+	return last >= v
+}
+
+// p2Quantile is a constant-space streaming estimator for a single quantile
+// q, implementing the P² algorithm of Jain & Chlamtac: five markers bracket
+// the quantile's neighborhood and are repositioned by a parabolic (falling
+// back to linear) prediction on every sample, so the estimate converges in
+// O(1) time and space per sample without ever retaining the samples
+// themselves. The first five samples are buffered to seed the markers.
+type p2Quantile struct {
+	q       float64
+	initial []float64 // buffers the first 5 samples before the markers are seeded
+
+	heights [5]float64 // marker heights (the estimate lives at heights[2])
+	pos     [5]int     // actual marker positions
+	desired [5]float64 // desired marker positions
+	incr    [5]float64 // desired position increment per sample
+}
+
+func newP2Quantile(q float64) *p2Quantile {
+	return &p2Quantile{q: q}
+}
+
+// Add folds x into the estimator.
+func (e *p2Quantile) Add(x float64) {
+	if len(e.initial) < 5 {
+		e.initial = append(e.initial, x)
+		if len(e.initial) == 5 {
+			sort.Float64s(e.initial)
+			for i := 0; i < 5; i++ {
+				e.heights[i] = e.initial[i]
+				e.pos[i] = i + 1
+			}
+			e.desired = [5]float64{1, 1 + 2*e.q, 1 + 4*e.q, 3 + 2*e.q, 5}
+			e.incr = [5]float64{0, e.q / 2, e.q, (1 + e.q) / 2, 1}
+		}
+		return
+	}
+
+	k := 3
+	switch {
+	case x < e.heights[0]:
+		e.heights[0] = x
+		k = 0
+	case x >= e.heights[4]:
+		e.heights[4] = x
+		k = 3
+	default:
+		for i := 0; i < 4; i++ {
+			if e.heights[i] <= x && x < e.heights[i+1] {
+				k = i
+				break
+			}
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		e.pos[i]++
+	}
+	for i := 0; i < 5; i++ {
+		e.desired[i] += e.incr[i]
+	}
+
+	for i := 1; i <= 3; i++ {
+		d := e.desired[i] - float64(e.pos[i])
+		if (d >= 1 && e.pos[i+1]-e.pos[i] > 1) || (d <= -1 && e.pos[i-1]-e.pos[i] < -1) {
+			sign := 1
+			if d < 0 {
+				sign = -1
+			}
+			newHeight := e.parabolic(i, sign)
+			if e.heights[i-1] < newHeight && newHeight < e.heights[i+1] {
+				e.heights[i] = newHeight
+			} else {
+				e.heights[i] = e.linear(i, sign)
+			}
+			e.pos[i] += sign
+		}
+	}
+}
+
+func (e *p2Quantile) parabolic(i, d int) float64 {
+	fd := float64(d)
+	return e.heights[i] + fd/float64(e.pos[i+1]-e.pos[i-1])*
+		(float64(e.pos[i]-e.pos[i-1]+d)*(e.heights[i+1]-e.heights[i])/float64(e.pos[i+1]-e.pos[i])+
+			float64(e.pos[i+1]-e.pos[i]-d)*(e.heights[i]-e.heights[i-1])/float64(e.pos[i]-e.pos[i-1]))
+}
+
+func (e *p2Quantile) linear(i, d int) float64 {
+	j := i + d
+	return e.heights[i] + float64(d)*(e.heights[j]-e.heights[i])/float64(e.pos[j]-e.pos[i])
+}
+
+// Value returns the current quantile estimate. Before the 5th sample it
+// falls back to an exact quantile of whatever's been buffered so far, and
+// ready is false only when no samples have been added yet.
+func (e *p2Quantile) Value() (v float64, ready bool) {
+	n := len(e.initial)
+	if n == 0 {
+		return 0, false
+	}
+	if n < 5 {
+		tmp := make([]float64, n)
+		copy(tmp, e.initial)
+		sort.Float64s(tmp)
+		idx := int(e.q * float64(n-1))
+		return tmp[idx], true
+	}
+	return e.heights[2], true
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sort.Float64s(xs)
+	n := len(xs)
+	if n%2 == 1 {
+		return xs[n/2]
+	}
+	return (xs[n/2-1] + xs[n/2]) / 2
+}
@@ -0,0 +1,95 @@
+package algorithms
+
+import "testing"
+
+func TestMedianWindowClassifierHotColdSplit(t *testing.T) {
+	c := NewMedianWindowClassifier()
+	// median([1,2,3,4,5]) == 3; last (5) >= 3 => hot.
+	if !c.IsHot("b1", []float64{1, 2, 3, 4, 5}) {
+		t.Fatalf("expected hot when last >= median")
+	}
+	// median([5,4,3,2,1]) == 3; last (1) < 3 => cold.
+	if c.IsHot("b2", []float64{5, 4, 3, 2, 1}) {
+		t.Fatalf("expected cold when last < median")
+	}
+}
+
+func TestMedianWindowClassifierCachesUntilHistoryChanges(t *testing.T) {
+	c := NewMedianWindowClassifier()
+	history := []float64{1, 2, 3}
+	if !c.IsHot("b1", history) {
+		t.Fatalf("expected hot: last (3) >= median (2)")
+	}
+	// Same length/last sample: cached median is reused, same verdict.
+	if !c.IsHot("b1", history) {
+		t.Fatalf("expected cached verdict to stay hot")
+	}
+	// History grows with a low tail: median rises, last sample now cold.
+	grown := []float64{1, 2, 3, 100, 100, 0}
+	if c.IsHot("b1", grown) {
+		t.Fatalf("expected cold after history grew with a low last sample")
+	}
+}
+
+func TestEWMAClassifierBootstrapsColdOnFirstSample(t *testing.T) {
+	c := NewEWMAClassifier(1.0, 0.5)
+	// First sample seeds the EWMA to itself, so last == ewma => not > => cold.
+	if c.IsHot("b1", []float64{10}) {
+		t.Fatalf("expected cold on the bootstrap sample")
+	}
+}
+
+func TestEWMAClassifierHotAboveThreshold(t *testing.T) {
+	c := NewEWMAClassifier(1.0, 0.5)
+	c.IsHot("b1", []float64{10})
+	// ewma after sample 2: 0.5*20 + 0.5*10 = 15; last (20) > 15*1.0 => hot.
+	if !c.IsHot("b1", []float64{10, 20}) {
+		t.Fatalf("expected hot once last exceeds Alpha*ewma")
+	}
+}
+
+func TestEWMAClassifierColdBelowThreshold(t *testing.T) {
+	c := NewEWMAClassifier(1.0, 0.5)
+	c.IsHot("b1", []float64{10})
+	// ewma after sample 2: 0.5*5 + 0.5*10 = 7.5; last (5) < 7.5 => cold.
+	if c.IsHot("b1", []float64{10, 5}) {
+		t.Fatalf("expected cold when last stays below Alpha*ewma")
+	}
+}
+
+func TestP2QuantileClassifierFallsBackToExactQuantileBeforeFiveSamples(t *testing.T) {
+	c := NewP2QuantileClassifier(0.75)
+	// With fewer than 5 samples, P2QuantileClassifier falls back to an exact
+	// quantile of the buffered samples, same as p2Quantile.Value does. Only
+	// the latest sample of a history is folded in, so the buffer holds [3].
+	if !c.IsHot("b1", []float64{1, 2, 3}) {
+		t.Fatalf("expected hot: last (3) is the only buffered sample")
+	}
+}
+
+func TestP2QuantileClassifierMatchesAnalyticQuantileAfterSeeding(t *testing.T) {
+	c := NewP2QuantileClassifier(0.75)
+	// A uniform 1..20 stream seeds markers whose estimate converges near the
+	// analytic 75th percentile (~15). 20 is well above it, so it's hot; 1 is
+	// well below, so it's cold.
+	history := make([]float64, 0, 20)
+	for i := 1; i <= 20; i++ {
+		history = append(history, float64(i))
+		c.IsHot("b1", history)
+	}
+	withHot := append(append([]float64(nil), history...), 20)
+	if !c.IsHot("b1", withHot) {
+		t.Fatalf("expected hot: last (20) is far above the 75th percentile")
+	}
+	withCold := append(append([]float64(nil), withHot...), 1)
+	if c.IsHot("b1", withCold) {
+		t.Fatalf("expected cold: last (1) is far below the 75th percentile")
+	}
+}
+
+func TestP2QuantileDefaultsInvalidQuantile(t *testing.T) {
+	c := NewP2QuantileClassifier(0)
+	if c.Quantile != 0.75 {
+		t.Fatalf("expected default quantile 0.75, got %v", c.Quantile)
+	}
+}
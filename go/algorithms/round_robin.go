@@ -1,6 +1,8 @@
 package algorithms
 
 import (
+	"net/http"
+
 	"openprequal/abstractions"
 	"openprequal/contracts"
 )
@@ -27,3 +29,9 @@ func (r *RoundRobin) Next() *contracts.Backend {
 	r.idx = (r.idx + 1) % len(healthy)
 	return &b
 }
+
+// Select implements SelectionPolicy. RoundRobin already tracks its own state
+// against the registry it was built with, so req and backends are unused.
+func (r *RoundRobin) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return r.Next()
+}
@@ -0,0 +1,155 @@
+package algorithms
+
+import (
+	"hash/fnv"
+	"net"
+	"net/http"
+	"sync"
+
+	"openprequal/contracts"
+)
+
+// SelectionPolicy picks a backend from the candidate set for an incoming
+// request. It lets the proxy swap selection strategies (round robin,
+// hashing, Prequal, ...) via config without its handler code knowing which
+// one is in use.
+type SelectionPolicy interface {
+	Select(req *http.Request, backends []contracts.Backend) *contracts.Backend
+}
+
+// healthyOf filters backends down to the healthy subset, preserving order.
+func healthyOf(backends []contracts.Backend) []contracts.Backend {
+	healthy := make([]contracts.Backend, 0, len(backends))
+	for _, b := range backends {
+		if b.Health {
+			healthy = append(healthy, b)
+		}
+	}
+	return healthy
+}
+
+// stableHash returns a deterministic hash of key. This package has no
+// external dependencies, so it uses the standard library's FNV-1a rather
+// than pulling in a third-party hash like xxhash.
+func stableHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// hashSelect picks the healthy backend at key's hash modulo len(healthy).
+func hashSelect(healthy []contracts.Backend, key string) *contracts.Backend {
+	if len(healthy) == 0 {
+		return nil
+	}
+	idx := stableHash(key) % uint64(len(healthy))
+	return &healthy[idx]
+}
+
+// WeightedRoundRobin implements smooth weighted round robin: every pick adds
+// each backend's Weight to its running currentWeight, the backend with the
+// highest currentWeight wins, and the winner's currentWeight is then reduced
+// by the sum of all weights. Backends with Weight <= 0 are treated as 1.
+type WeightedRoundRobin struct {
+	mu             sync.Mutex
+	currentWeights map[string]float64
+}
+
+func NewWeightedRoundRobin() *WeightedRoundRobin {
+	return &WeightedRoundRobin{currentWeights: make(map[string]float64)}
+}
+
+func (w *WeightedRoundRobin) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	healthy := healthyOf(backends)
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	totalWeight := 0.0
+	bestIdx := -1
+	for i, b := range healthy {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		totalWeight += weight
+		w.currentWeights[b.URL] += weight
+		if bestIdx == -1 || w.currentWeights[b.URL] > w.currentWeights[healthy[bestIdx].URL] {
+			bestIdx = i
+		}
+	}
+	w.currentWeights[healthy[bestIdx].URL] -= totalWeight
+	return &healthy[bestIdx]
+}
+
+// HeaderHash routes requests carrying the same value for Header to the same
+// backend, for sticky routing driven by a caller-supplied header.
+type HeaderHash struct{ Header string }
+
+func NewHeaderHash(header string) *HeaderHash { return &HeaderHash{Header: header} }
+
+func (h *HeaderHash) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return hashSelect(healthyOf(backends), req.Header.Get(h.Header))
+}
+
+// CookieHash routes requests carrying the same value for Cookie to the same
+// backend, e.g. for session affinity.
+type CookieHash struct{ Cookie string }
+
+func NewCookieHash(cookie string) *CookieHash { return &CookieHash{Cookie: cookie} }
+
+func (c *CookieHash) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	key := ""
+	if ck, err := req.Cookie(c.Cookie); err == nil {
+		key = ck.Value
+	}
+	return hashSelect(healthyOf(backends), key)
+}
+
+// IPHash routes requests from the same client IP to the same backend.
+type IPHash struct{}
+
+func NewIPHash() *IPHash { return &IPHash{} }
+
+func (*IPHash) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return hashSelect(healthyOf(backends), host)
+}
+
+// URIHash routes requests for the same path to the same backend, which
+// keeps per-URI caches on the backends warm.
+type URIHash struct{}
+
+func NewURIHash() *URIHash { return &URIHash{} }
+
+func (*URIHash) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	return hashSelect(healthyOf(backends), req.URL.Path)
+}
+
+// FirstAvailable walks healthy backends in the order given and returns the
+// first one whose InFlightRequests is under Threshold, falling back to the
+// first healthy backend if none qualify.
+type FirstAvailable struct{ Threshold float64 }
+
+func NewFirstAvailable(threshold float64) *FirstAvailable {
+	return &FirstAvailable{Threshold: threshold}
+}
+
+func (f *FirstAvailable) Select(req *http.Request, backends []contracts.Backend) *contracts.Backend {
+	healthy := healthyOf(backends)
+	for i := range healthy {
+		if healthy[i].InFlightRequests < f.Threshold {
+			return &healthy[i]
+		}
+	}
+	if len(healthy) > 0 {
+		return &healthy[0]
+	}
+	return nil
+}
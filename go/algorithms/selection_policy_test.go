@@ -0,0 +1,92 @@
+package algorithms
+
+import (
+	"net/http"
+	"testing"
+
+	"openprequal/contracts"
+)
+
+func healthyBackends(urls ...string) []contracts.Backend {
+	out := make([]contracts.Backend, 0, len(urls))
+	for _, u := range urls {
+		b := contracts.NewBackend(u, nil)
+		b.Health = true
+		out = append(out, *b)
+	}
+	return out
+}
+
+func TestWeightedRoundRobinHonorsWeights(t *testing.T) {
+	backends := healthyBackends("a", "b")
+	backends[0].Weight = 3
+	backends[1].Weight = 1
+
+	wrr := NewWeightedRoundRobin()
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		chosen := wrr.Select(req, backends)
+		counts[chosen.URL]++
+	}
+	if counts["a"] != 6 || counts["b"] != 2 {
+		t.Fatalf("expected a:6 b:2, got %v", counts)
+	}
+}
+
+func TestHeaderHashIsSticky(t *testing.T) {
+	backends := healthyBackends("a", "b", "c")
+	h := NewHeaderHash("X-Session-Id")
+
+	req1, _ := http.NewRequest("GET", "http://proxy/", nil)
+	req1.Header.Set("X-Session-Id", "user-42")
+	req2, _ := http.NewRequest("GET", "http://proxy/other", nil)
+	req2.Header.Set("X-Session-Id", "user-42")
+
+	first := h.Select(req1, backends)
+	second := h.Select(req2, backends)
+	if first == nil || second == nil || first.URL != second.URL {
+		t.Fatalf("expected the same backend for the same header value, got %v and %v", first, second)
+	}
+}
+
+func TestFirstAvailableSkipsOverloadedBackends(t *testing.T) {
+	backends := healthyBackends("a", "b")
+	backends[0].InFlightRequests = 20
+	backends[1].InFlightRequests = 1
+
+	fa := NewFirstAvailable(10)
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+
+	chosen := fa.Select(req, backends)
+	if chosen == nil || chosen.URL != "b" {
+		t.Fatalf("expected b (under threshold), got %v", chosen)
+	}
+}
+
+func TestFirstAvailableFallsBackWhenAllOverloaded(t *testing.T) {
+	backends := healthyBackends("a")
+	backends[0].InFlightRequests = 50
+
+	fa := NewFirstAvailable(10)
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+
+	chosen := fa.Select(req, backends)
+	if chosen == nil || chosen.URL != "a" {
+		t.Fatalf("expected fallback to a, got %v", chosen)
+	}
+}
+
+func TestURIHashSkipsUnhealthyBackends(t *testing.T) {
+	backends := healthyBackends("a", "b")
+	backends[1].Health = false
+
+	u := NewURIHash()
+	req, _ := http.NewRequest("GET", "http://proxy/path", nil)
+
+	chosen := u.Select(req, backends)
+	if chosen == nil || chosen.URL != "a" {
+		t.Fatalf("expected only healthy backend a, got %v", chosen)
+	}
+}
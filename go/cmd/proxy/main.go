@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,53 +20,166 @@ import (
 	"openprequal/config"
 	"openprequal/contracts"
 	"openprequal/core"
+	probegrpc "openprequal/core/probe/grpc"
+	"openprequal/events"
+	"openprequal/metrics"
 )
 
 // This binary provides a proxy that uses the Prequal load balancer and the
 // core proxy handler to forward requests to selected backends.
 func main() {
+	// eventBus carries BackendRegistered/BackendUnregistered/HealthChanged/
+	// ProbeCompleted/PolicySelected events from the registry, health
+	// checker, probe pool, and selection policy to the /events SSE endpoint
+	// and, if configured, an external sink, so topology changes don't have
+	// to be reconstructed by polling ListBackends.
+	eventBus := events.NewBus()
+	defer eventBus.Close()
+
 	// Create core components
 	probePool := core.NewProbePool()
+	probePool.SetBus(eventBus)
 	probeQueue := core.NewProbeTaskQueue()
 	// Probe manager requires endpoint and maxConcurrent; use defaults here
 	probeManager := core.NewProbeManager(probePool, probeQueue, "", 4)
 
 	// Registry using the in-memory implementation
 	registry := abstractions.NewInMemoryRegistry()
+	registry.SetBus(eventBus)
+
+	// Backends are probed over HTTP or gRPC per their ProbeScheme; the gRPC
+	// Prober keeps one persistent StreamMetrics connection per backend
+	// instead of dialing fresh for every probe.
+	probeManager.SetProbers(registry, map[string]core.Prober{
+		"http": core.NewHTTPProber("/probe", 2*time.Second),
+		"grpc": probegrpc.NewProber(),
+	})
+
+	// inflightTracker gives selection policies like LeastRIF a live view of
+	// requests in flight, rather than relying solely on the last heartbeat.
+	// Built before the selection policy switch below since least_rif needs
+	// it at construction time.
+	inflightTracker := core.NewInflightTracker()
 
-	// Allow selecting the load balancer implementation via env (LOAD_BALANCER_CLASS)
-	// supported short keys: default|prequal, round_robin, random, least_latency,
-	// least_latency_p2, least_rif, least_rif_p2
+	// Allow selecting the selection policy via env (LOAD_BALANCER_CLASS).
+	// Policies are looked up in algorithms' global registry by name, so
+	// adding a new one doesn't require touching this switch; supported
+	// built-in keys: default|prequal, round_robin, random, least_latency,
+	// least_latency_p2, least_rif, least_rif_p2, weighted_round_robin,
+	// header_hash, cookie_hash, ip_hash, uri_hash, first_available.
 	cfg := config.NewFromEnv()
-	var lb interface{ Next() *contracts.Backend }
-	switch cfg.LoadBalancerClass {
-	case "round_robin":
-		log.Printf("using load balancer: round_robin")
-		lb = algorithms.NewRoundRobin(registry)
-	case "random":
-		log.Printf("using load balancer: random")
-		lb = algorithms.NewRandomLB(registry)
-	case "least_latency":
-		log.Printf("using load balancer: least_latency")
-		lb = algorithms.NewLeastLatencyLB(registry)
-	case "least_latency_p2":
-		log.Printf("using load balancer: least_latency_p2")
-		lb = algorithms.NewLeastLatencyP2(registry)
-	case "least_rif":
-		log.Printf("using load balancer: least_rif")
-		lb = algorithms.NewLeastRIF(registry)
-	case "least_rif_p2":
-		log.Printf("using load balancer: least_rif_p2")
-		lb = algorithms.NewLeastRIFP2(registry)
-	case "default", "prequal":
-		fallthrough
-	default:
-		log.Printf("using load balancer: prequal (default)")
-		lb = algorithms.NewPrequal(registry, probePool, probeQueue)
+	policyCtx := algorithms.PolicyContext{
+		Registry:          registry,
+		ProbePool:         probePool,
+		ProbeQueue:        probeQueue,
+		Inflight:          inflightTracker,
+		HeaderName:        cfg.SelectionHeaderName,
+		CookieName:        cfg.SelectionCookieName,
+		FirstAvailableRIF: cfg.FirstAvailableRIF,
+	}
+	policyName := cfg.LoadBalancerClass
+	factory, ok := algorithms.Get(policyName)
+	if !ok {
+		log.Printf("unknown selection policy %q, falling back to prequal", policyName)
+		metrics.ObservePolicyFallback(policyName)
+		policyName = "prequal"
+		factory, _ = algorithms.Get(policyName)
+	}
+	lb, err := factory(policyCtx)
+	if err != nil {
+		log.Fatalf("failed to build selection policy %q: %v", policyName, err)
+	}
+	if prequal, ok := lb.(*algorithms.Prequal); ok {
+		prequal.SetBus(eventBus)
 	}
+	log.Printf("using selection policy: %s", policyName)
 
-	// Create proxy handler with default http client
-	proxyHandler := core.NewProxyHandler(&http.Client{})
+	// lbSelector is non-nil when lb has a managed lifecycle and a feedback
+	// surface (currently just Prequal); a SelectionPolicy that has neither
+	// simply doesn't implement LBSelector, so this stays nil for it.
+	lbSelector, _ := lb.(algorithms.LBSelector)
+
+	// Create proxy handler with a plain HTTP transport; FastCGI backends are
+	// only supported when FASTCGI_SCRIPT_FILENAME is set since PHP-FPM style
+	// servers need a script path to dispatch to.
+	httpTransport := core.NewHTTPTransport(core.HTTPTransportOptions{
+		RequestTimeout: time.Duration(cfg.RequestTimeoutMS) * time.Millisecond,
+	})
+	var fastcgiTransport core.Transport
+	if scriptFilename := os.Getenv("FASTCGI_SCRIPT_FILENAME"); scriptFilename != "" {
+		fastcgiTransport = core.NewFastCGITransport(scriptFilename, os.Getenv("FASTCGI_DOCUMENT_ROOT"))
+	}
+	proxyHandler := core.NewProxyHandler(httpTransport, fastcgiTransport)
+	proxyHandler.SetInflightTracker(inflightTracker)
+
+	// Active health checks run independently of the Prequal probe pool and
+	// flip registry.SetHealth on their own schedule; proxied 5xx/connection
+	// errors also trip a backend early via RecordProxyResult, and sustained
+	// high latency trips it via RecordProxyLatency if HEALTH_LATENCY_THRESHOLD_MS
+	// is set.
+	healthChecker := core.NewHealthChecker(registry, core.DefaultHealthCheckConfig(), 3)
+	healthChecker.SetBus(eventBus)
+	if v := os.Getenv("HEALTH_LATENCY_THRESHOLD_MS"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil {
+			healthChecker.SetLatencyThreshold(ms)
+		}
+	}
+
+	// recordOutcome feeds a single attempt's result and latency back into
+	// HealthChecker's passive tripping, regardless of whether retries are in
+	// play.
+	recordOutcome := func(backend *contracts.Backend, resp *http.Response, err error, latencyMS float64) {
+		if err != nil {
+			healthChecker.RecordProxyResult(backend.URL, false)
+		} else {
+			healthChecker.RecordProxyResult(backend.URL, resp.StatusCode < 500)
+		}
+		healthChecker.RecordProxyLatency(backend.URL, latencyMS)
+	}
+
+	// Per-backend circuit breakers short-circuit a misbehaving backend for a
+	// cool-down instead of proxying to it; CIRCUIT_BREAKER_CONFIG can hold a
+	// JSON override of the trip condition, window, and fallback, re-read on
+	// SIGHUP so it can be tuned without a restart.
+	breakerConfig := core.DefaultBreakerConfig()
+	if raw := os.Getenv("CIRCUIT_BREAKER_CONFIG"); raw != "" {
+		parsed, err := core.ParseBreakerConfig(raw)
+		if err != nil {
+			log.Fatalf("invalid CIRCUIT_BREAKER_CONFIG: %v", err)
+		}
+		breakerConfig = parsed
+	}
+	breakers := core.NewCircuitBreakerManager(registry, breakerConfig)
+	proxyHandler.SetCircuitBreakers(breakers)
+
+	// Proxy-side metrics, mainly so retry attempts are observable; the
+	// Prequal probe pool tracks backend-reported latency/RIF separately.
+	// Named proxyMetrics, not metrics, so it doesn't shadow the imported
+	// metrics package used for selection-policy observability below.
+	proxyMetrics := core.NewMetricsManager(nil)
+
+	// On a retryable failure, retryExecutor re-invokes lb.Select against a
+	// different healthy backend rather than giving up with a 502. Retries
+	// are opt-out via RETRY_ENABLED since replaying a non-idempotent request
+	// against a second backend isn't always safe.
+	var retryExecutor *core.RetryExecutor
+	if cfg.RetryEnabled {
+		retryPolicy := core.NewRetryPolicy(
+			cfg.RetryMaxAttempts,
+			time.Duration(cfg.RetryBaseDelayMS)*time.Millisecond,
+			time.Duration(cfg.RetryMaxDelayMS)*time.Millisecond,
+			cfg.RetryFactor,
+			cfg.RetryJitter,
+			cfg.RetryStatusCodes,
+		)
+		retryExecutor = core.NewRetryExecutor(proxyHandler, retryPolicy, proxyMetrics)
+		retryExecutor.OnAttempt = recordOutcome
+		retryExecutor.Probes = probePool
+		retryExecutor.Hedge = core.HedgeConfig{
+			Enabled: cfg.HedgeEnabled,
+			After:   time.Duration(cfg.HedgeAfterMS) * time.Millisecond,
+		}
+	}
 
 	// Register management endpoints on default mux
 	// /register - backend posts its info
@@ -96,33 +213,156 @@ func main() {
 		json.NewEncoder(w).Encode(map[string]string{"status": "unregistered"})
 	})
 
-	// simple /metrics endpoint for readiness checks
-	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+	// /metrics exposes every collector registered in the metrics package
+	// (selection counts/latency, probed backend RIF/latency, Prequal
+	// hot/cold classifications, probes scheduled) for Prometheus scraping.
+	http.Handle("/metrics", metrics.Handler())
+
+	// /healthz reports what HealthChecker currently believes about every
+	// backend it has checked, so operators can see who was ejected and why.
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(healthChecker.Status())
+	})
+
+	// /events streams every bus event as NDJSON over SSE for live debugging;
+	// it uses DropOldest so a slow or disconnected client can't back up
+	// publishers, at the cost of that client missing events under load.
+	http.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		ch, unsubscribe := eventBus.Subscribe(64, nil, events.DropOldest)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("# metrics placeholder\n"))
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-ch:
+				if !ok {
+					return
+				}
+				payload, err := json.Marshal(e)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "data: %s\n\n", payload)
+				flusher.Flush()
+			}
+		}
 	})
 
+	// EVENTS_NATS_URL/EVENTS_NATS_SUBJECT or EVENTS_KAFKA_BROKERS/
+	// EVENTS_KAFKA_TOPIC optionally forward every event to an external sink
+	// so a controller outside this process can react to topology changes.
+	if natsURL := os.Getenv("EVENTS_NATS_URL"); natsURL != "" {
+		subject := os.Getenv("EVENTS_NATS_SUBJECT")
+		if subject == "" {
+			subject = "openprequal.events"
+		}
+		sink, err := events.NewNATSSink(natsURL, subject)
+		if err != nil {
+			log.Fatalf("EVENTS_NATS_URL set but failed to connect: %v", err)
+		}
+		defer sink.Close()
+		stop := events.Forward(eventBus, sink)
+		defer stop()
+	}
+	if brokers := os.Getenv("EVENTS_KAFKA_BROKERS"); brokers != "" {
+		topic := os.Getenv("EVENTS_KAFKA_TOPIC")
+		if topic == "" {
+			topic = "openprequal.events"
+		}
+		sink := events.NewKafkaSink(strings.Split(brokers, ","), topic)
+		defer sink.Close()
+		stop := events.Forward(eventBus, sink)
+		defer stop()
+	}
+
 	// default proxy handler
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		backend := lb.Next()
-		if backend == nil {
-			http.Error(w, "no backends", http.StatusServiceUnavailable)
-			return
+		selectBackend := func(excluded map[string]bool) *contracts.Backend {
+			healthy := registry.ListBackends()
+			candidates := healthy
+			if len(excluded) > 0 {
+				candidates = make([]contracts.Backend, 0, len(healthy))
+				for _, b := range healthy {
+					if !excluded[b.URL] {
+						candidates = append(candidates, b)
+					}
+				}
+			}
+
+			start := time.Now()
+			picked := lb.Select(r, candidates)
+			metrics.LBPickLatencySeconds.WithLabelValues(policyName).Observe(time.Since(start).Seconds())
+			if picked == nil {
+				metrics.ObserveSelection(policyName, "", "empty")
+			} else {
+				metrics.ObserveSelection(policyName, picked.URL, "ok")
+			}
+			return picked
 		}
-		if resp, err := proxyHandler.HandleProxy(r, backend.URL); err != nil {
-			http.Error(w, err.Error(), http.StatusBadGateway)
+
+		var resp *http.Response
+		var err error
+		if retryExecutor != nil {
+			resp, err = retryExecutor.Execute(r, selectBackend)
 		} else {
-			defer resp.Body.Close()
-			w.WriteHeader(resp.StatusCode)
-			_, _ = io.Copy(w, resp.Body)
+			backend := selectBackend(nil)
+			if backend == nil {
+				http.Error(w, "no backends", http.StatusServiceUnavailable)
+				return
+			}
+			if lbSelector != nil {
+				// If the client disconnects before HandleProxy returns, tell
+				// the policy to abandon any workload it scheduled on this
+				// backend's behalf (e.g. a Prequal probe round) instead of
+				// letting it run to completion for nothing.
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					select {
+					case <-r.Context().Done():
+						lbSelector.CancelWorkload(backend.URL)
+					case <-done:
+					}
+				}()
+			}
+			start := time.Now()
+			resp, err = proxyHandler.HandleProxy(r, backend)
+			latencyMS := float64(time.Since(start)) / float64(time.Millisecond)
+			probePool.AddProbe(backend.URL, latencyMS, 0)
+			recordOutcome(backend, resp, err, latencyMS)
+			if lbSelector != nil {
+				lbSelector.UpdateCostMetrics(backend.URL, latencyMS)
+			}
 		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
 	})
 
 	srv := &http.Server{Addr: ":8000", Handler: nil}
 
-	// Start probe manager
+	// Start probe manager and health checker
 	ctx, cancel := context.WithCancel(context.Background())
 	probeManager.Run(ctx)
+	healthChecker.Start(ctx)
 
 	// Start HTTP server
 	go func() {
@@ -132,6 +372,46 @@ func main() {
 		}
 	}()
 
+	// GRPC_HEALTH_ADDR exposes grpc.health.v1.Health for the proxy itself,
+	// so orchestrators (Kubernetes gRPC probes, Consul, etc.) can check it
+	// with a standard protocol instead of parsing the placeholder /metrics.
+	if grpcHealthAddr := os.Getenv("GRPC_HEALTH_ADDR"); grpcHealthAddr != "" {
+		lis, err := net.Listen("tcp", grpcHealthAddr)
+		if err != nil {
+			log.Fatalf("failed to listen on %s: %v", grpcHealthAddr, err)
+		}
+		grpcHealthServer := probegrpc.NewProxyHealthServer()
+		go func() {
+			log.Printf("starting proxy gRPC health server on %s", grpcHealthAddr)
+			if err := grpcHealthServer.Serve(lis); err != nil {
+				log.Printf("grpc health server stopped: %v", err)
+			}
+		}()
+	}
+
+	// SIGHUP re-reads CIRCUIT_BREAKER_CONFIG so the trip condition can be
+	// tuned without a restart.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			raw := os.Getenv("CIRCUIT_BREAKER_CONFIG")
+			if raw == "" {
+				continue
+			}
+			cfg, err := core.ParseBreakerConfig(raw)
+			if err != nil {
+				log.Printf("SIGHUP: invalid CIRCUIT_BREAKER_CONFIG, keeping previous config: %v", err)
+				continue
+			}
+			if err := breakers.ReloadDefaultConfig(cfg); err != nil {
+				log.Printf("SIGHUP: failed to reload circuit breaker config: %v", err)
+				continue
+			}
+			log.Printf("SIGHUP: reloaded circuit breaker config")
+		}
+	}()
+
 	// Wait for shutdown signal
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -139,6 +419,9 @@ func main() {
 
 	// Shutdown
 	cancel()
+	if lbSelector != nil {
+		_ = lbSelector.Close()
+	}
 	ctxTimeout, cancelTimeout := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelTimeout()
 	_ = srv.Shutdown(ctxTimeout)
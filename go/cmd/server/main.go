@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -10,8 +11,22 @@ import (
 	"openprequal/config"
 	"openprequal/contracts"
 	"openprequal/core"
+	probegrpc "openprequal/core/probe/grpc"
 )
 
+// grpcSource adapts a MetricsManager to probe/grpc.Source so this backend
+// can serve PrequalProbe.StreamMetrics and grpc.health.v1.Health alongside
+// its HTTP /probe endpoint.
+type grpcSource struct {
+	metrics *core.MetricsManager
+}
+
+func (s grpcSource) Healthy() bool { return true }
+
+func (s grpcSource) Metrics() probegrpc.Metrics {
+	return probegrpc.Metrics{RIF: float64(s.metrics.GetInFlight()), AvgLatencyMS: s.metrics.GetAvgLatency()}
+}
+
 func main() {
 	// create metrics manager (no rif bins)
 	metrics := core.NewMetricsManager(nil)
@@ -45,6 +60,23 @@ func main() {
 		_ = json.NewEncoder(w).Encode(resp)
 	})
 
+	// GRPC_ADDR opts this backend into the gRPC probe path (PrequalProbe +
+	// grpc.health.v1.Health) alongside the HTTP one above; set
+	// Backend.ProbeScheme="grpc" on the proxy side to have it probed here.
+	if grpcAddr := os.Getenv("GRPC_ADDR"); grpcAddr != "" {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("failed to listen on %s: %v", grpcAddr, err)
+		}
+		grpcServer := probegrpc.NewServer(grpcSource{metrics: metrics}, 0)
+		go func() {
+			log.Printf("starting backend gRPC probe server on %s", grpcAddr)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Printf("grpc server stopped: %v", err)
+			}
+		}()
+	}
+
 	// Prefer explicit PORT env var (useful in some container runtimes),
 	// otherwise use configured backend port so the server actually listens
 	// on the same port it reports in its Backend model.
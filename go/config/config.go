@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds application configuration loaded from environment variables.
@@ -21,6 +22,22 @@ type Config struct {
 	CustomRequestHook    string
 	CustomResponseHook   string
 	LoadBalancerClass    string
+	SelectionHeaderName  string
+	SelectionCookieName  string
+	FirstAvailableRIF    float64
+
+	RetryEnabled     bool
+	RetryMaxAttempts int
+	RetryBaseDelayMS int
+	RetryMaxDelayMS  int
+	RetryFactor      float64
+	RetryJitter      float64
+	RetryStatusCodes []int
+
+	RequestTimeoutMS int
+
+	HedgeEnabled bool
+	HedgeAfterMS int
 }
 
 // NewFromEnv creates a Config by reading environment variables and applying defaults.
@@ -52,6 +69,29 @@ func NewFromEnv() *Config {
 	c.CustomResponseHook = os.Getenv("CUSTOM_RESPONSE_HOOK")
 
 	c.LoadBalancerClass = getenv("LOAD_BALANCER_CLASS", "default")
+	c.SelectionHeaderName = getenv("SELECTION_HEADER_NAME", "X-Session-Id")
+	c.SelectionCookieName = getenv("SELECTION_COOKIE_NAME", "session_id")
+	c.FirstAvailableRIF = getenvFloat("FIRST_AVAILABLE_RIF_THRESHOLD", 10.0)
+
+	// Retries are opt-out: set RETRY_ENABLED=false for non-idempotent
+	// workloads where replaying a request against a second backend isn't safe.
+	c.RetryEnabled = getenvBool("RETRY_ENABLED", true)
+	c.RetryMaxAttempts = getenvInt("RETRY_MAX_ATTEMPTS", 3)
+	c.RetryBaseDelayMS = getenvInt("RETRY_BASE_DELAY_MS", 1000)
+	c.RetryMaxDelayMS = getenvInt("RETRY_MAX_DELAY_MS", 120000)
+	c.RetryFactor = getenvFloat("RETRY_FACTOR", 1.6)
+	c.RetryJitter = getenvFloat("RETRY_JITTER", 0.2)
+	c.RetryStatusCodes = getenvIntList("RETRY_STATUS_CODES", []int{502, 503, 504})
+
+	// RequestTimeoutMS bounds a single backend RoundTrip; 0 leaves it
+	// unbounded beyond whatever deadline the inbound request's context carries.
+	c.RequestTimeoutMS = getenvInt("REQUEST_TIMEOUT_MS", 0)
+
+	// Hedging is opt-in: it only pays off once backends are numerous enough
+	// that firing a second request rarely just doubles load on the same
+	// slow backend.
+	c.HedgeEnabled = getenvBool("HEDGE_ENABLED", false)
+	c.HedgeAfterMS = getenvInt("HEDGE_AFTER_MS", 50)
 
 	return c
 }
@@ -71,3 +111,39 @@ func getenvInt(key string, def int) int {
 	}
 	return def
 }
+
+func getenvFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func getenvBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// getenvIntList parses a comma-separated list of ints, e.g. "502,503,504".
+func getenvIntList(key string, def []int) []int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []int
+	for _, part := range strings.Split(v, ",") {
+		if i, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			out = append(out, i)
+		}
+	}
+	if len(out) == 0 {
+		return def
+	}
+	return out
+}
@@ -22,6 +22,51 @@ func TestNewFromEnvDefaults(t *testing.T) {
 	if c.BackendURL == "" {
 		t.Fatalf("expected BackendURL to be set")
 	}
+	if !c.RetryEnabled {
+		t.Fatalf("expected retries to be enabled by default")
+	}
+	if c.RetryMaxAttempts != 3 {
+		t.Fatalf("unexpected RetryMaxAttempts default: %d", c.RetryMaxAttempts)
+	}
+	if len(c.RetryStatusCodes) != 3 || c.RetryStatusCodes[0] != 502 {
+		t.Fatalf("unexpected RetryStatusCodes default: %v", c.RetryStatusCodes)
+	}
+	if c.HedgeEnabled {
+		t.Fatalf("expected hedging to be disabled by default")
+	}
+	if c.HedgeAfterMS != 50 {
+		t.Fatalf("unexpected HedgeAfterMS default: %d", c.HedgeAfterMS)
+	}
+}
+
+func TestNewFromEnvHedgeOverrides(t *testing.T) {
+	os.Setenv("HEDGE_ENABLED", "true")
+	os.Setenv("HEDGE_AFTER_MS", "25")
+	defer os.Unsetenv("HEDGE_ENABLED")
+	defer os.Unsetenv("HEDGE_AFTER_MS")
+
+	c := NewFromEnv()
+	if !c.HedgeEnabled {
+		t.Fatalf("expected HEDGE_ENABLED=true to enable hedging")
+	}
+	if c.HedgeAfterMS != 25 {
+		t.Fatalf("unexpected HedgeAfterMS override: %d", c.HedgeAfterMS)
+	}
+}
+
+func TestNewFromEnvRetryOverrides(t *testing.T) {
+	os.Setenv("RETRY_ENABLED", "false")
+	os.Setenv("RETRY_STATUS_CODES", "500, 502")
+	defer os.Unsetenv("RETRY_ENABLED")
+	defer os.Unsetenv("RETRY_STATUS_CODES")
+
+	c := NewFromEnv()
+	if c.RetryEnabled {
+		t.Fatalf("expected RETRY_ENABLED=false to disable retries")
+	}
+	if len(c.RetryStatusCodes) != 2 || c.RetryStatusCodes[1] != 502 {
+		t.Fatalf("unexpected RetryStatusCodes override: %v", c.RetryStatusCodes)
+	}
 }
 
 func TestSetupLoggingCreatesFile(t *testing.T) {
@@ -6,6 +6,10 @@ type Backend struct {
 	Health           bool    `json:"health"`
 	InFlightRequests float64 `json:"in_flight_requests"`
 	AvgLatency       float64 `json:"avg_latency"`
+	Scheme           string  `json:"scheme,omitempty"`         // "http", "https", or "fastcgi"; defaults to "http"
+	TransportKind    string  `json:"transport_kind,omitempty"` // "http" or "fastcgi"; defaults to "http"
+	Weight           float64 `json:"weight,omitempty"`         // relative share for WeightedRoundRobin; <= 0 is treated as 1
+	ProbeScheme      string  `json:"probe_scheme,omitempty"`   // "http" or "grpc"; selects the Prober ProbeManager uses, defaults to "http"
 }
 
 func NewBackend(url string, port *int) *Backend {
@@ -40,5 +44,9 @@ func (b *Backend) ModelDump() map[string]interface{} {
 		"health":             b.Health,
 		"in_flight_requests": b.InFlightRequests,
 		"avg_latency":        b.AvgLatency,
+		"scheme":             b.Scheme,
+		"transport_kind":     b.TransportKind,
+		"weight":             b.Weight,
+		"probe_scheme":       b.ProbeScheme,
 	}
 }
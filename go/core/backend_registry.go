@@ -2,6 +2,7 @@ package core
 
 import (
 	"openprequal/contracts"
+	"openprequal/events"
 	"strconv"
 	"sync"
 	"time"
@@ -12,6 +13,7 @@ type BackendRegistry struct {
 	backends         map[string]*contracts.Backend
 	lastHeartbeat    map[string]time.Time
 	heartbeatTimeout time.Duration
+	bus              *events.Bus
 }
 
 func NewBackendRegistry(timeoutSeconds int) *BackendRegistry {
@@ -20,21 +22,29 @@ func NewBackendRegistry(timeoutSeconds int) *BackendRegistry {
 }
 func keyFor(url string, port int) string { return url + ":" + strconv.Itoa(port) }
 
+// SetBus makes Register/Unregister publish BackendRegistered/
+// BackendUnregistered events; nil disables publishing (the default).
+func (r *BackendRegistry) SetBus(bus *events.Bus) {
+	r.bus = bus
+}
+
 func (r *BackendRegistry) Register(b *contracts.Backend) map[string]interface{} {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	k := keyFor(b.URL, 0)
 	r.backends[k] = b
 	r.lastHeartbeat[k] = time.Now()
 	b.Health = true
+	r.mu.Unlock()
+	r.bus.Publish(events.Event{Kind: events.BackendRegistered, Backend: b.URL})
 	return map[string]interface{}{"status": "registered"}
 }
 
 func (r *BackendRegistry) Unregister(b *contracts.Backend) map[string]interface{} {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	delete(r.backends, keyFor(b.URL, 0))
 	delete(r.lastHeartbeat, keyFor(b.URL, 0))
+	r.mu.Unlock()
+	r.bus.Publish(events.Event{Kind: events.BackendUnregistered, Backend: b.URL})
 	return map[string]interface{}{"status": "unregistered"}
 }
 
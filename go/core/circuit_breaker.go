@@ -0,0 +1,688 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"openprequal/abstractions"
+)
+
+// breakerState is the Closed/Open/HalfOpen state of a single CircuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker. TripExpr is a small boolean
+// expression over a rolling window, combining any of:
+//
+//	NetworkErrorRatio()                     - connection errors / total requests
+//	LatencyAtQuantileMS(q)                  - the q-th percentile latency in ms
+//	ResponseCodeRatio(lo1, hi1, lo2, hi2)    - count of status in [lo1,hi1) / count in [lo2,hi2)
+//
+// joined with && / || and compared with >, >=, <, <=, or ==, e.g.
+// "NetworkErrorRatio() > 0.5 || LatencyAtQuantileMS(50.0) > 200".
+type BreakerConfig struct {
+	TripExpr string
+
+	// BucketDuration is the width of each tumbling bucket (default 10s) and
+	// NumBuckets is how many are kept in the rolling window (default 6, i.e.
+	// a 60s window).
+	BucketDuration time.Duration
+	NumBuckets     int
+
+	// CooldownBase is how long a freshly-opened breaker stays Open before
+	// admitting a HalfOpen trial request (default 5s). CooldownMax caps the
+	// exponential back-off applied each time a trial request fails
+	// (default 60s).
+	CooldownBase time.Duration
+	CooldownMax  time.Duration
+
+	// FallbackURL, if set, is proxied to (over plain HTTP) in place of the
+	// tripped backend. If unset, or the fallback request itself fails,
+	// FallbackStatus/FallbackBody is returned as a static response
+	// (FallbackStatus defaults to 503).
+	FallbackURL    string
+	FallbackStatus int
+	FallbackBody   string
+}
+
+// DefaultBreakerConfig returns a breaker that trips when more than half of
+// requests in the rolling window are network errors, or p50 latency exceeds
+// 200ms, and short-circuits with a static 503 while open.
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		TripExpr:       "NetworkErrorRatio() > 0.5 || LatencyAtQuantileMS(50.0) > 200",
+		BucketDuration: 10 * time.Second,
+		NumBuckets:     6,
+		CooldownBase:   5 * time.Second,
+		CooldownMax:    60 * time.Second,
+		FallbackStatus: http.StatusServiceUnavailable,
+		FallbackBody:   "circuit breaker open",
+	}
+}
+
+// breakerConfigJSON is the wire format accepted by ParseBreakerConfig; it
+// exists so BreakerConfig can use time.Duration while the JSON/env form uses
+// plain milliseconds.
+type breakerConfigJSON struct {
+	TripExpr         string `json:"trip_expr"`
+	BucketDurationMS int    `json:"bucket_duration_ms"`
+	NumBuckets       int    `json:"num_buckets"`
+	CooldownBaseMS   int    `json:"cooldown_base_ms"`
+	CooldownMaxMS    int    `json:"cooldown_max_ms"`
+	FallbackURL      string `json:"fallback_url"`
+	FallbackStatus   int    `json:"fallback_status"`
+	FallbackBody     string `json:"fallback_body"`
+}
+
+// ParseBreakerConfig parses a BreakerConfig from a JSON string, the form it
+// is carried in as an env var (e.g. CIRCUIT_BREAKER_CONFIG) so it can be
+// reloaded at runtime without a restart. TripExpr is validated eagerly so a
+// bad reload is rejected before it replaces a working config.
+func ParseBreakerConfig(raw string) (BreakerConfig, error) {
+	var j breakerConfigJSON
+	if err := json.Unmarshal([]byte(raw), &j); err != nil {
+		return BreakerConfig{}, fmt.Errorf("circuit_breaker: invalid config JSON: %w", err)
+	}
+	cfg := BreakerConfig{
+		TripExpr:       j.TripExpr,
+		BucketDuration: time.Duration(j.BucketDurationMS) * time.Millisecond,
+		NumBuckets:     j.NumBuckets,
+		CooldownBase:   time.Duration(j.CooldownBaseMS) * time.Millisecond,
+		CooldownMax:    time.Duration(j.CooldownMaxMS) * time.Millisecond,
+		FallbackURL:    j.FallbackURL,
+		FallbackStatus: j.FallbackStatus,
+		FallbackBody:   j.FallbackBody,
+	}
+	if cfg.TripExpr == "" {
+		cfg = DefaultBreakerConfig()
+		cfg.FallbackURL, cfg.FallbackStatus, cfg.FallbackBody = j.FallbackURL, j.FallbackStatus, j.FallbackBody
+	}
+	if _, err := parseTripExpr(cfg.TripExpr); err != nil {
+		return BreakerConfig{}, err
+	}
+	if cfg.FallbackStatus == 0 {
+		cfg.FallbackStatus = http.StatusServiceUnavailable
+	}
+	return cfg, nil
+}
+
+// --- rolling window -------------------------------------------------------
+
+// bucketStats accumulates the raw counters for one tumbling bucket.
+type bucketStats struct {
+	total         int
+	networkErrors int
+	statusCounts  map[int]int
+	latenciesMS   []float64
+}
+
+// window is a tumbling-bucket ring: Record always writes into the bucket for
+// "now", and stats only ever consider buckets within the last NumBuckets*
+// BucketDuration, so old traffic ages out automatically.
+type window struct {
+	mu             sync.Mutex
+	bucketDuration time.Duration
+	numBuckets     int
+	buckets        map[int64]*bucketStats
+}
+
+func newWindow(bucketDuration time.Duration, numBuckets int) *window {
+	if bucketDuration <= 0 {
+		bucketDuration = 10 * time.Second
+	}
+	if numBuckets <= 0 {
+		numBuckets = 6
+	}
+	return &window{bucketDuration: bucketDuration, numBuckets: numBuckets, buckets: make(map[int64]*bucketStats)}
+}
+
+func (w *window) indexFor(t time.Time) int64 {
+	return t.UnixNano() / int64(w.bucketDuration)
+}
+
+// record adds one observation and evicts buckets that have aged out of the
+// window.
+func (w *window) record(now time.Time, statusCode int, latencyMS float64, networkErr bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx := w.indexFor(now)
+	b, ok := w.buckets[idx]
+	if !ok {
+		b = &bucketStats{statusCounts: make(map[int]int)}
+		w.buckets[idx] = b
+	}
+	b.total++
+	if networkErr {
+		b.networkErrors++
+	} else {
+		b.statusCounts[statusCode]++
+	}
+	b.latenciesMS = append(b.latenciesMS, latencyMS)
+
+	cutoff := idx - int64(w.numBuckets)
+	for k := range w.buckets {
+		if k <= cutoff {
+			delete(w.buckets, k)
+		}
+	}
+}
+
+// live returns the buckets still inside the window as of now, without
+// mutating the window (unlike record, it does not evict).
+func (w *window) live(now time.Time) []*bucketStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx := w.indexFor(now)
+	cutoff := idx - int64(w.numBuckets)
+	out := make([]*bucketStats, 0, len(w.buckets))
+	for k, b := range w.buckets {
+		if k > cutoff {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// networkErrorRatio returns networkErrors/total across the live window, 0 if
+// there have been no requests.
+func (w *window) networkErrorRatio(now time.Time) float64 {
+	total, netErr := 0, 0
+	for _, b := range w.live(now) {
+		total += b.total
+		netErr += b.networkErrors
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(netErr) / float64(total)
+}
+
+// latencyAtQuantile returns the q-th percentile (0-100) latency in
+// milliseconds across the live window, 0 if there are no samples.
+func (w *window) latencyAtQuantile(now time.Time, q float64) float64 {
+	var samples []float64
+	for _, b := range w.live(now) {
+		samples = append(samples, b.latenciesMS...)
+	}
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Float64s(samples)
+	idx := int(q / 100.0 * float64(len(samples)-1))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(samples) {
+		idx = len(samples) - 1
+	}
+	return samples[idx]
+}
+
+// responseCodeRatio returns the count of statuses in [loA,hiA) divided by
+// the count in [loB,hiB), both across the live window. 0 if the denominator
+// is 0.
+func (w *window) responseCodeRatio(now time.Time, loA, hiA, loB, hiB float64) float64 {
+	num, denom := 0, 0
+	for _, b := range w.live(now) {
+		for code, count := range b.statusCounts {
+			c := float64(code)
+			if c >= loA && c < hiA {
+				num += count
+			}
+			if c >= loB && c < hiB {
+				denom += count
+			}
+		}
+	}
+	if denom == 0 {
+		return 0
+	}
+	return float64(num) / float64(denom)
+}
+
+// --- trip expression parser ----------------------------------------------
+
+// tripExpr is a parsed, ready-to-evaluate boolean expression over a window.
+type tripExpr interface {
+	eval(w *window, now time.Time) bool
+}
+
+type orExpr struct{ left, right tripExpr }
+
+func (e *orExpr) eval(w *window, now time.Time) bool {
+	return e.left.eval(w, now) || e.right.eval(w, now)
+}
+
+type andExpr struct{ left, right tripExpr }
+
+func (e *andExpr) eval(w *window, now time.Time) bool {
+	return e.left.eval(w, now) && e.right.eval(w, now)
+}
+
+type cmpExpr struct {
+	stat      func(w *window, now time.Time) float64
+	op        string
+	threshold float64
+}
+
+func (e *cmpExpr) eval(w *window, now time.Time) bool {
+	v := e.stat(w, now)
+	switch e.op {
+	case ">":
+		return v > e.threshold
+	case ">=":
+		return v >= e.threshold
+	case "<":
+		return v < e.threshold
+	case "<=":
+		return v <= e.threshold
+	case "==":
+		return v == e.threshold
+	default:
+		return false
+	}
+}
+
+// tripExprTokenRE matches identifiers, numbers, parens, commas, and the
+// &&/||/comparison operators the trip-expression grammar understands.
+var tripExprTokenRE = regexp.MustCompile(`&&|\|\||>=|<=|==|[A-Za-z_][A-Za-z0-9_]*|[0-9]+(?:\.[0-9]+)?|[(),><]`)
+
+// exprTokens tokenizes raw for parseTripExpr.
+func exprTokens(raw string) []string {
+	return tripExprTokenRE.FindAllString(raw, -1)
+}
+
+// parseTripExpr compiles a trip-condition string (see BreakerConfig.TripExpr)
+// into an evaluable tripExpr.
+func parseTripExpr(raw string) (tripExpr, error) {
+	tokens := exprTokens(raw)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("circuit_breaker: empty trip expression")
+	}
+	p := &exprParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("circuit_breaker: unexpected token %q in trip expression %q", p.tokens[p.pos], raw)
+	}
+	return expr, nil
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *exprParser) parseOr() (tripExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (tripExpr, error) {
+	left, err := p.parseCmp()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseCmp()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseCmp() (tripExpr, error) {
+	name := p.next()
+	if name == "" {
+		return nil, fmt.Errorf("circuit_breaker: expected a function call")
+	}
+	if p.next() != "(" {
+		return nil, fmt.Errorf("circuit_breaker: expected '(' after %q", name)
+	}
+	var args []float64
+	for p.peek() != ")" {
+		if p.peek() == "" {
+			return nil, fmt.Errorf("circuit_breaker: unterminated argument list for %q", name)
+		}
+		tok := p.next()
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("circuit_breaker: %q is not a number", tok)
+		}
+		args = append(args, v)
+		if p.peek() == "," {
+			p.next()
+		}
+	}
+	p.next() // consume ")"
+
+	stat, err := bindStatFunc(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	switch op {
+	case ">", ">=", "<", "<=", "==":
+	default:
+		return nil, fmt.Errorf("circuit_breaker: expected a comparison operator, got %q", op)
+	}
+	thresholdTok := p.next()
+	threshold, err := strconv.ParseFloat(thresholdTok, 64)
+	if err != nil {
+		return nil, fmt.Errorf("circuit_breaker: %q is not a number", thresholdTok)
+	}
+	return &cmpExpr{stat: stat, op: op, threshold: threshold}, nil
+}
+
+// bindStatFunc resolves a function name + parsed args to a stat function
+// closed over those args, validating arity along the way.
+func bindStatFunc(name string, args []float64) (func(w *window, now time.Time) float64, error) {
+	switch name {
+	case "NetworkErrorRatio":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("circuit_breaker: NetworkErrorRatio takes no arguments")
+		}
+		return func(w *window, now time.Time) float64 { return w.networkErrorRatio(now) }, nil
+	case "LatencyAtQuantileMS":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("circuit_breaker: LatencyAtQuantileMS takes exactly 1 argument")
+		}
+		q := args[0]
+		return func(w *window, now time.Time) float64 { return w.latencyAtQuantile(now, q) }, nil
+	case "ResponseCodeRatio":
+		if len(args) != 4 {
+			return nil, fmt.Errorf("circuit_breaker: ResponseCodeRatio takes exactly 4 arguments")
+		}
+		loA, hiA, loB, hiB := args[0], args[1], args[2], args[3]
+		return func(w *window, now time.Time) float64 { return w.responseCodeRatio(now, loA, hiA, loB, hiB) }, nil
+	default:
+		return nil, fmt.Errorf("circuit_breaker: unknown trip function %q", name)
+	}
+}
+
+// --- breaker + manager -----------------------------------------------------
+
+// CircuitBreaker is a per-backend Closed/Open/HalfOpen breaker. Use
+// CircuitBreakerManager rather than constructing one directly.
+type CircuitBreaker struct {
+	mu      sync.Mutex
+	cfg     BreakerConfig
+	trip    tripExpr
+	window  *window
+	state   breakerState
+	openAt  time.Time
+	backoff time.Duration
+
+	onStateChange func(open bool)
+}
+
+func newCircuitBreaker(cfg BreakerConfig, onStateChange func(open bool)) (*CircuitBreaker, error) {
+	trip, err := parseTripExpr(cfg.TripExpr)
+	if err != nil {
+		return nil, err
+	}
+	return &CircuitBreaker{
+		cfg:           cfg,
+		trip:          trip,
+		window:        newWindow(cfg.BucketDuration, cfg.NumBuckets),
+		backoff:       cfg.CooldownBase,
+		onStateChange: onStateChange,
+	}, nil
+}
+
+// allow reports whether a request may proceed to the backend. While Open it
+// returns false until the cooldown elapses, at which point it flips to
+// HalfOpen and admits exactly one trial request.
+func (cb *CircuitBreaker) allow(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case breakerOpen:
+		if now.Sub(cb.openAt) < cb.backoff {
+			return false
+		}
+		cb.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// A trial is already outstanding; everything else short-circuits
+		// until it resolves.
+		return false
+	default:
+		return true
+	}
+}
+
+// recordResult feeds a completed request's outcome back into the breaker.
+func (cb *CircuitBreaker) recordResult(now time.Time, statusCode int, latencyMS float64, networkErr bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.window.record(now, statusCode, latencyMS, networkErr)
+
+	switch cb.state {
+	case breakerHalfOpen:
+		if networkErr || statusCode >= 500 {
+			cb.openLocked(now)
+			cb.backoff *= 2
+			if cb.backoff > cb.cfg.CooldownMax {
+				cb.backoff = cb.cfg.CooldownMax
+			}
+		} else {
+			cb.state = breakerClosed
+			cb.backoff = cb.cfg.CooldownBase
+			if cb.onStateChange != nil {
+				cb.onStateChange(false)
+			}
+		}
+	case breakerClosed:
+		if cb.trip.eval(cb.window, now) {
+			cb.openLocked(now)
+		}
+	}
+}
+
+// openLocked transitions to Open; callers must hold cb.mu.
+func (cb *CircuitBreaker) openLocked(now time.Time) {
+	cb.state = breakerOpen
+	cb.openAt = now
+	if cb.onStateChange != nil {
+		cb.onStateChange(true)
+	}
+}
+
+func (cb *CircuitBreaker) currentState() breakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// CircuitBreakerManager owns one CircuitBreaker per backend URL and wraps
+// ProxyHandler's calls to a backend with it. Tripping a breaker also flips
+// the backend's Health flag in the registry (the same mechanism
+// HealthChecker uses), so load balancers that filter on Backend.Health
+// already avoid selecting an open backend without changes of their own.
+type CircuitBreakerManager struct {
+	mu            sync.Mutex
+	registry      abstractions.Registry
+	defaultConfig BreakerConfig
+	overrides     map[string]BreakerConfig
+	breakers      map[string]*CircuitBreaker
+	httpClient    *http.Client
+}
+
+// NewCircuitBreakerManager creates a manager using defaultConfig for any
+// backend without a SetConfig override.
+func NewCircuitBreakerManager(registry abstractions.Registry, defaultConfig BreakerConfig) *CircuitBreakerManager {
+	return &CircuitBreakerManager{
+		registry:      registry,
+		defaultConfig: defaultConfig,
+		overrides:     make(map[string]BreakerConfig),
+		breakers:      make(map[string]*CircuitBreaker),
+		httpClient:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// SetConfig overrides the breaker configuration for a single backend URL,
+// replacing its breaker (and resetting its rolling window) immediately.
+func (m *CircuitBreakerManager) SetConfig(url string, cfg BreakerConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cb, err := newCircuitBreaker(cfg, m.onStateChangeFor(url))
+	if err != nil {
+		return err
+	}
+	m.overrides[url] = cfg
+	m.breakers[url] = cb
+	return nil
+}
+
+// ReloadDefaultConfig replaces the configuration used for every backend that
+// has no per-backend override, without restarting the process. Backends with
+// live breakers keep their accumulated window under the old config; only
+// newly-created breakers pick up the change. Call SetConfig again per
+// backend to force an immediate reset.
+func (m *CircuitBreakerManager) ReloadDefaultConfig(cfg BreakerConfig) error {
+	if _, err := parseTripExpr(cfg.TripExpr); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultConfig = cfg
+	return nil
+}
+
+func (m *CircuitBreakerManager) onStateChangeFor(url string) func(open bool) {
+	return func(open bool) {
+		if m.registry != nil {
+			m.registry.SetHealth(url, !open)
+		}
+	}
+}
+
+func (m *CircuitBreakerManager) breakerFor(url string) (*CircuitBreaker, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cb, ok := m.breakers[url]; ok {
+		return cb, nil
+	}
+	cfg, ok := m.overrides[url]
+	if !ok {
+		cfg = m.defaultConfig
+	}
+	cb, err := newCircuitBreaker(cfg, m.onStateChangeFor(url))
+	if err != nil {
+		return nil, err
+	}
+	m.breakers[url] = cb
+	return cb, nil
+}
+
+// Allow reports whether a request to url may proceed.
+func (m *CircuitBreakerManager) Allow(url string) (bool, error) {
+	cb, err := m.breakerFor(url)
+	if err != nil {
+		return true, err
+	}
+	return cb.allow(time.Now()), nil
+}
+
+// RecordResult feeds a completed request's outcome back into url's breaker.
+func (m *CircuitBreakerManager) RecordResult(url string, statusCode int, latencyMS float64, networkErr bool) {
+	cb, err := m.breakerFor(url)
+	if err != nil {
+		return
+	}
+	cb.recordResult(time.Now(), statusCode, latencyMS, networkErr)
+}
+
+// Fallback builds the response to return in place of a short-circuited
+// request: a proxied response from FallbackURL if configured and reachable,
+// else a static response built from FallbackStatus/FallbackBody.
+func (m *CircuitBreakerManager) Fallback(url string, req *http.Request) *http.Response {
+	cfg, ok := m.overrides[url]
+	if !ok {
+		m.mu.Lock()
+		cfg = m.defaultConfig
+		m.mu.Unlock()
+	}
+	if cfg.FallbackURL != "" {
+		if fallbackReq, err := http.NewRequest(req.Method, cfg.FallbackURL+req.URL.Path, req.Body); err == nil {
+			fallbackReq.Header = req.Header
+			if resp, err := m.httpClient.Do(fallbackReq); err == nil {
+				return resp
+			}
+		}
+	}
+	status := cfg.FallbackStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader(cfg.FallbackBody)),
+		Header:     make(http.Header),
+	}
+}
+
+// IsOpen reports whether url's breaker is currently tripped (Open or
+// HalfOpen with its trial still outstanding).
+func (m *CircuitBreakerManager) IsOpen(url string) bool {
+	cb, err := m.breakerFor(url)
+	if err != nil {
+		return false
+	}
+	return cb.currentState() != breakerClosed
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"openprequal/abstractions"
+	"openprequal/contracts"
+)
+
+func TestParseTripExprEvaluatesComparisons(t *testing.T) {
+	expr, err := parseTripExpr("NetworkErrorRatio() > 0.5 || LatencyAtQuantileMS(50.0) > 200")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	w := newWindow(10*time.Second, 6)
+	now := time.Unix(1000, 0)
+	w.record(now, 200, 50, false)
+	if expr.eval(w, now) {
+		t.Fatalf("expected expression to be false for healthy traffic")
+	}
+	w.record(now, 0, 0, true)
+	w.record(now, 0, 0, true)
+	if !expr.eval(w, now) {
+		t.Fatalf("expected expression to trip once NetworkErrorRatio exceeds 0.5")
+	}
+}
+
+func TestParseTripExprRejectsBadInput(t *testing.T) {
+	cases := []string{
+		"",
+		"NotAFunction() > 1",
+		"NetworkErrorRatio(",
+		"NetworkErrorRatio() ?? 1",
+		"LatencyAtQuantileMS(50, 60) > 1",
+	}
+	for _, c := range cases {
+		if _, err := parseTripExpr(c); err == nil {
+			t.Fatalf("expected parse error for %q", c)
+		}
+	}
+}
+
+func TestWindowResponseCodeRatio(t *testing.T) {
+	w := newWindow(10*time.Second, 6)
+	now := time.Unix(2000, 0)
+	w.record(now, 500, 10, false)
+	w.record(now, 200, 10, false)
+	w.record(now, 200, 10, false)
+	ratio := w.responseCodeRatio(now, 500, 600, 0, 600)
+	if ratio < 0.33 || ratio > 0.34 {
+		t.Fatalf("expected ~1/3 5xx ratio, got %v", ratio)
+	}
+}
+
+func TestWindowEvictsOldBuckets(t *testing.T) {
+	w := newWindow(1*time.Second, 2)
+	start := time.Unix(10000, 0)
+	w.record(start, 200, 10, false)
+	later := start.Add(10 * time.Second)
+	w.record(later, 200, 10, false)
+	if ratio := w.networkErrorRatio(later); ratio != 0 {
+		t.Fatalf("expected 0 error ratio, got %v", ratio)
+	}
+	if total := len(w.live(later)); total != 1 {
+		t.Fatalf("expected the stale bucket to have aged out, got %d live buckets", total)
+	}
+}
+
+func TestCircuitBreakerOpensHalfOpensAndCloses(t *testing.T) {
+	cfg := BreakerConfig{
+		TripExpr:       "NetworkErrorRatio() > 0.5",
+		BucketDuration: 10 * time.Second,
+		NumBuckets:     6,
+		CooldownBase:   10 * time.Millisecond,
+		CooldownMax:    100 * time.Millisecond,
+	}
+	var lastOpen *bool
+	cb, err := newCircuitBreaker(cfg, func(open bool) { lastOpen = &open })
+	if err != nil {
+		t.Fatalf("unexpected error building breaker: %v", err)
+	}
+
+	now := time.Unix(5000, 0)
+	if !cb.allow(now) {
+		t.Fatalf("expected a closed breaker to allow requests")
+	}
+	cb.recordResult(now, 0, 5, true)
+	cb.recordResult(now, 0, 5, true)
+	if cb.currentState() != breakerOpen {
+		t.Fatalf("expected breaker to open after exceeding NetworkErrorRatio threshold")
+	}
+	if lastOpen == nil || !*lastOpen {
+		t.Fatalf("expected onStateChange(true) to fire when the breaker opened")
+	}
+
+	if cb.allow(now) {
+		t.Fatalf("expected an open breaker within its cooldown to reject requests")
+	}
+
+	trial := now.Add(20 * time.Millisecond)
+	if !cb.allow(trial) {
+		t.Fatalf("expected the breaker to admit a trial request once the cooldown elapsed")
+	}
+	if cb.currentState() != breakerHalfOpen {
+		t.Fatalf("expected breaker to be half-open while the trial is outstanding")
+	}
+	if cb.allow(trial) {
+		t.Fatalf("expected a second concurrent request to be rejected while a trial is outstanding")
+	}
+
+	cb.recordResult(trial, 200, 5, false)
+	if cb.currentState() != breakerClosed {
+		t.Fatalf("expected a successful trial to close the breaker")
+	}
+	if lastOpen == nil || *lastOpen {
+		t.Fatalf("expected onStateChange(false) to fire when the breaker closed")
+	}
+}
+
+func TestCircuitBreakerManagerTripsRegistryHealth(t *testing.T) {
+	registry := abstractions.NewInMemoryRegistry()
+	registry.Register(contracts.Backend{URL: "http://backend", Health: true})
+
+	cfg := BreakerConfig{
+		TripExpr:       "NetworkErrorRatio() > 0.5",
+		BucketDuration: 10 * time.Second,
+		NumBuckets:     6,
+		CooldownBase:   time.Minute,
+		CooldownMax:    time.Minute,
+		FallbackStatus: 503,
+	}
+	m := NewCircuitBreakerManager(registry, cfg)
+
+	m.RecordResult("http://backend", 0, 5, true)
+	m.RecordResult("http://backend", 0, 5, true)
+
+	if !m.IsOpen("http://backend") {
+		t.Fatalf("expected breaker to be open")
+	}
+	if registry.ListBackends()[0].Health {
+		t.Fatalf("expected registry to reflect the tripped backend as unhealthy")
+	}
+
+	allowed, err := m.Allow("http://backend")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if allowed {
+		t.Fatalf("expected Allow to reject requests while open and within cooldown")
+	}
+}
+
+func TestParseBreakerConfigValidatesTripExpr(t *testing.T) {
+	if _, err := ParseBreakerConfig(`{"trip_expr": "NotAFunction() > 1"}`); err == nil {
+		t.Fatalf("expected an invalid trip expression to be rejected")
+	}
+	cfg, err := ParseBreakerConfig(`{"trip_expr": "NetworkErrorRatio() > 0.2", "cooldown_base_ms": 1000}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CooldownBase != time.Second {
+		t.Fatalf("expected CooldownBase to be 1s, got %v", cfg.CooldownBase)
+	}
+}
@@ -0,0 +1,312 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"openprequal/contracts"
+)
+
+// FastCGI record types and constants, per the spec at
+// https://fastcgi-archives.github.io/FastCGI_Specification.html.
+const (
+	fcgiVersion1 = 1
+
+	fcgiBeginRequest = 1
+	fcgiEndRequest   = 3
+	fcgiParams       = 4
+	fcgiStdin        = 5
+	fcgiStdout       = 6
+	fcgiStderr       = 7
+
+	fcgiRoleResponder = 1
+
+	fcgiRequestID   = 1 // FastCGITransport only ever issues one request per connection
+	fcgiMaxRecord   = 65535
+	fcgiDialTimeout = 5 * time.Second
+)
+
+// FastCGITransport forwards requests to a FastCGI application server (e.g.
+// PHP-FPM) over a TCP or Unix socket, encoding CGI parameters and the
+// request body as FastCGI records and decoding the stdout stream back into
+// an *http.Response.
+type FastCGITransport struct {
+	// ScriptFilename is sent unchanged as SCRIPT_FILENAME for every request
+	// (the request path goes in REQUEST_URI), mirroring a typical PHP-FPM
+	// front controller setup.
+	ScriptFilename string
+	DocumentRoot   string
+}
+
+// NewFastCGITransport creates a FastCGITransport. scriptFilename and
+// documentRoot populate the SCRIPT_FILENAME and DOCUMENT_ROOT CGI params
+// sent with every request.
+func NewFastCGITransport(scriptFilename, documentRoot string) *FastCGITransport {
+	return &FastCGITransport{ScriptFilename: scriptFilename, DocumentRoot: documentRoot}
+}
+
+// dialNetwork returns "unix" for a backend URL that names a socket path
+// (starting with "/" or "unix:"), otherwise "tcp".
+func dialNetwork(addr string) (network, target string) {
+	if len(addr) > 0 && addr[0] == '/' {
+		return "unix", addr
+	}
+	const unixPrefix = "unix:"
+	if len(addr) > len(unixPrefix) && addr[:len(unixPrefix)] == unixPrefix {
+		return "unix", addr[len(unixPrefix):]
+	}
+	return "tcp", addr
+}
+
+// RoundTrip sends req to backend's FastCGI server and returns its response.
+func (t *FastCGITransport) RoundTrip(req *http.Request, backend *contracts.Backend) (*http.Response, error) {
+	network, addr := dialNetwork(backend.URL)
+	conn, err := net.DialTimeout(network, addr, fcgiDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("fastcgi: dial %s: %w", backend.URL, err)
+	}
+	defer conn.Close()
+
+	if err := writeFCGIRecord(conn, fcgiBeginRequest, fcgiRequestID, beginRequestBody(fcgiRoleResponder)); err != nil {
+		return nil, err
+	}
+	params := t.cgiParams(req)
+	if err := writeFCGIParams(conn, params); err != nil {
+		return nil, err
+	}
+	if err := writeFCGIStdin(conn, req.Body); err != nil {
+		return nil, err
+	}
+
+	return readFCGIResponse(conn, req)
+}
+
+// cgiParams builds the standard CGI environment variables describing req.
+func (t *FastCGITransport) cgiParams(req *http.Request) map[string]string {
+	remoteAddr, remotePort := splitHostPort(req.RemoteAddr)
+	params := map[string]string{
+		"SCRIPT_FILENAME":   t.ScriptFilename,
+		"DOCUMENT_ROOT":     t.DocumentRoot,
+		"REQUEST_METHOD":    req.Method,
+		"REQUEST_URI":       req.URL.RequestURI(),
+		"QUERY_STRING":      req.URL.RawQuery,
+		"SERVER_PROTOCOL":   req.Proto,
+		"GATEWAY_INTERFACE": "CGI/1.1",
+		"REMOTE_ADDR":       remoteAddr,
+		"REMOTE_PORT":       remotePort,
+		"CONTENT_TYPE":      req.Header.Get("Content-Type"),
+	}
+	if req.ContentLength >= 0 {
+		params["CONTENT_LENGTH"] = strconv.FormatInt(req.ContentLength, 10)
+	}
+	for name, values := range req.Header {
+		if len(values) == 0 {
+			continue
+		}
+		params["HTTP_"+headerToCGIName(name)] = values[0]
+	}
+	return params
+}
+
+func splitHostPort(remoteAddr string) (host, port string) {
+	host, port, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr, ""
+	}
+	return host, port
+}
+
+// headerToCGIName converts "Content-Type" style header names into the
+// "CONTENT_TYPE" form CGI/FastCGI expects after the HTTP_ prefix.
+func headerToCGIName(name string) string {
+	out := make([]byte, len(name))
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '-' {
+			out[i] = '_'
+		} else if c >= 'a' && c <= 'z' {
+			out[i] = c - ('a' - 'A')
+		} else {
+			out[i] = c
+		}
+	}
+	return string(out)
+}
+
+// beginRequestBody encodes the fixed 8-byte FCGI_BeginRequestBody record.
+func beginRequestBody(role uint16) []byte {
+	body := make([]byte, 8)
+	binary.BigEndian.PutUint16(body[0:2], role)
+	// flags left at 0: don't keep the connection open past this request
+	return body
+}
+
+// writeFCGIRecord writes content as one or more FastCGI records of at most
+// fcgiMaxRecord bytes each, each padded to a multiple of 8 bytes as
+// recommended by the spec. Empty content writes no record.
+func writeFCGIRecord(w io.Writer, recType uint8, requestID uint16, content []byte) error {
+	for len(content) > 0 {
+		chunk := content
+		if len(chunk) > fcgiMaxRecord {
+			chunk = chunk[:fcgiMaxRecord]
+		}
+		if err := writeFCGIChunk(w, recType, requestID, chunk); err != nil {
+			return err
+		}
+		content = content[len(chunk):]
+	}
+	return nil
+}
+
+func writeFCGIChunk(w io.Writer, recType uint8, requestID uint16, content []byte) error {
+	padding := (8 - len(content)%8) % 8
+	header := make([]byte, 8)
+	header[0] = fcgiVersion1
+	header[1] = recType
+	binary.BigEndian.PutUint16(header[2:4], requestID)
+	binary.BigEndian.PutUint16(header[4:6], uint16(len(content)))
+	header[6] = byte(padding)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(content) > 0 {
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+	}
+	if padding > 0 {
+		if _, err := w.Write(make([]byte, padding)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFCGIParams encodes params using the FastCGI name-value length
+// encoding and sends them as one or more FCGI_PARAMS records, terminated by
+// an empty one.
+func writeFCGIParams(w io.Writer, params map[string]string) error {
+	var buf bytes.Buffer
+	for name, value := range params {
+		writeNameValueLength(&buf, len(name))
+		writeNameValueLength(&buf, len(value))
+		buf.WriteString(name)
+		buf.WriteString(value)
+	}
+	if err := writeFCGIRecord(w, fcgiParams, fcgiRequestID, buf.Bytes()); err != nil {
+		return err
+	}
+	// empty FCGI_PARAMS record terminates the stream
+	return writeFCGIChunk(w, fcgiParams, fcgiRequestID, nil)
+}
+
+// writeNameValueLength encodes a name/value length per the FastCGI spec:
+// one byte if < 128, else a 4-byte big-endian length with the high bit set.
+func writeNameValueLength(buf *bytes.Buffer, n int) {
+	if n < 128 {
+		buf.WriteByte(byte(n))
+		return
+	}
+	length := uint32(n) | 0x80000000
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], length)
+	buf.Write(b[:])
+}
+
+// writeFCGIStdin streams body as FCGI_STDIN records, terminated by an empty
+// one, as required even for requests with no body.
+func writeFCGIStdin(w io.Writer, body io.Reader) error {
+	if body != nil {
+		buf := make([]byte, fcgiMaxRecord)
+		for {
+			n, err := body.Read(buf)
+			if n > 0 {
+				if werr := writeFCGIChunk(w, fcgiStdin, fcgiRequestID, buf[:n]); werr != nil {
+					return werr
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return writeFCGIChunk(w, fcgiStdin, fcgiRequestID, nil)
+}
+
+// readFCGIResponse reads FCGI_STDOUT records until FCGI_END_REQUEST and
+// parses the accumulated CGI output (headers + body) into an *http.Response.
+func readFCGIResponse(r io.Reader, req *http.Request) (*http.Response, error) {
+	var stdout bytes.Buffer
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("fastcgi: reading record header: %w", err)
+		}
+		recType := header[1]
+		contentLen := binary.BigEndian.Uint16(header[4:6])
+		padding := header[6]
+
+		content := make([]byte, contentLen)
+		if contentLen > 0 {
+			if _, err := io.ReadFull(r, content); err != nil {
+				return nil, fmt.Errorf("fastcgi: reading record body: %w", err)
+			}
+		}
+		if padding > 0 {
+			if _, err := io.CopyN(io.Discard, r, int64(padding)); err != nil {
+				return nil, err
+			}
+		}
+
+		switch recType {
+		case fcgiStdout:
+			stdout.Write(content)
+		case fcgiStderr:
+			// application diagnostics; not surfaced to the caller
+		case fcgiEndRequest:
+			return parseCGIResponse(&stdout, req)
+		}
+	}
+}
+
+// parseCGIResponse splits CGI-style "Status:"/header lines from the body
+// and builds an *http.Response, defaulting to 200 if no Status is present.
+func parseCGIResponse(stdout *bytes.Buffer, req *http.Request) (*http.Response, error) {
+	tp := textproto.NewReader(bufio.NewReader(stdout))
+	mimeHeader, err := tp.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("fastcgi: parsing headers: %w", err)
+	}
+	header := http.Header(mimeHeader)
+
+	statusCode := http.StatusOK
+	if status := header.Get("Status"); status != "" {
+		if code, convErr := strconv.Atoi(status[:3]); convErr == nil {
+			statusCode = code
+		}
+		header.Del("Status")
+	}
+
+	body := io.NopCloser(tp.R)
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Header:     header,
+		Body:       body,
+		Request:    req,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}, nil
+}
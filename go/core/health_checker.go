@@ -0,0 +1,493 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"openprequal/abstractions"
+	"openprequal/events"
+)
+
+// CheckMode selects how HealthChecker verifies a backend.
+type CheckMode string
+
+const (
+	// CheckModeHTTP issues an HTTP GET against Path and inspects the status
+	// code and, optionally, the response body.
+	CheckModeHTTP CheckMode = "http"
+	// CheckModeGRPC calls the standard grpc.health.v1.Health/Check RPC.
+	CheckModeGRPC CheckMode = "grpc"
+)
+
+// HealthCheckConfig tunes active checks for a single backend. The zero value
+// is not directly usable; build one with NewHealthCheckConfig or copy
+// DefaultHealthCheckConfig() and override fields.
+type HealthCheckConfig struct {
+	Mode CheckMode
+	// Method is the HTTP method used in CheckModeHTTP (e.g. "GET", "POST").
+	// Empty defaults to GET.
+	Method string
+	// Path is the HTTP path probed in CheckModeHTTP (e.g. "/healthz").
+	Path string
+	// MinStatus and MaxStatus bound the accepted HTTP status range,
+	// inclusive. A zero MaxStatus disables the upper bound check.
+	MinStatus int
+	MaxStatus int
+	// BodyRegex, if non-nil, must match the response body for the check to
+	// pass. Only used in CheckModeHTTP.
+	BodyRegex *regexp.Regexp
+	// TLSConfig, if non-nil, is used for https:// targets; leave nil to use
+	// the default transport settings (no client cert, system roots).
+	TLSConfig *tls.Config
+
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// HealthyThreshold is the number of consecutive passing checks required
+	// to mark a currently-unhealthy backend healthy again.
+	HealthyThreshold int
+	// UnhealthyThreshold is the number of consecutive failing checks
+	// required to mark a currently-healthy backend unhealthy.
+	UnhealthyThreshold int
+
+	// MaxQuarantineInterval bounds the exponential backoff applied to a
+	// backend's recheck interval while it stays unhealthy (see run), so a
+	// backend that's been down a while doesn't get re-probed at the same
+	// rate as a healthy one. <= 0 defaults to 10x Interval.
+	MaxQuarantineInterval time.Duration
+}
+
+// DefaultHealthCheckConfig returns sane defaults: an HTTP GET of "/healthz"
+// every 10s, expecting 200-399, with a 2s timeout and a threshold of 2
+// consecutive results in either direction.
+func DefaultHealthCheckConfig() HealthCheckConfig {
+	return HealthCheckConfig{
+		Mode:               CheckModeHTTP,
+		Method:             http.MethodGet,
+		Path:               "/healthz",
+		MinStatus:          200,
+		MaxStatus:          399,
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+	}
+}
+
+// backendState tracks the running tallies HealthChecker needs to apply
+// HealthyThreshold/UnhealthyThreshold hysteresis and to answer Status().
+type backendState struct {
+	mu                 sync.Mutex
+	healthy            bool
+	consecutivePass    int
+	consecutiveFail    int
+	consecutivePassive int // consecutive 5xx/connection errors observed passively
+	consecutiveLatency int // consecutive above-threshold latency samples observed passively
+	quarantineInterval time.Duration
+	lastCheck          time.Time
+	lastErr            error
+}
+
+// BackendHealth is the public snapshot returned by HealthChecker.Status,
+// used both in tests and by the /healthz management endpoint.
+type BackendHealth struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	LastCheck time.Time `json:"last_check"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// HealthChecker periodically re-verifies registered backends and flips
+// Backend.Health in an abstractions.Registry accordingly, independent of the
+// Prequal probe pool's latency/RIF measurements. It also accepts passive
+// signals from the proxy path (consecutive 5xx or connection errors) so a
+// backend can be ejected between active check intervals.
+type HealthChecker struct {
+	registry      abstractions.Registry
+	defaultConfig HealthCheckConfig
+	client        *http.Client
+
+	// passiveThreshold is the number of consecutive proxied failures (or
+	// above-threshold latency samples) that trips a backend unhealthy,
+	// regardless of what the active check says.
+	passiveThreshold int
+	// latencyThresholdMs enables RecordProxyLatency's passive trip once set
+	// positive via SetLatencyThreshold; <= 0 (the default) disables it.
+	latencyThresholdMs float64
+
+	mu       sync.Mutex
+	configs  map[string]HealthCheckConfig // per-backend overrides, keyed by URL
+	states   map[string]*backendState
+	cancel   context.CancelFunc
+	grpcConn map[string]*grpc.ClientConn
+	bus      *events.Bus
+}
+
+// SetBus makes HealthChecker publish a HealthChanged event whenever a
+// backend's Health flips, from either an active check or a passive trip;
+// nil disables publishing (the default).
+func (h *HealthChecker) SetBus(bus *events.Bus) {
+	h.mu.Lock()
+	h.bus = bus
+	h.mu.Unlock()
+}
+
+func (h *HealthChecker) publishHealthChanged(url string, healthy bool, reason string) {
+	h.mu.Lock()
+	bus := h.bus
+	h.mu.Unlock()
+	bus.Publish(events.Event{Kind: events.HealthChanged, Backend: url, Data: map[string]interface{}{"healthy": healthy, "reason": reason}})
+}
+
+// NewHealthChecker creates a HealthChecker that applies defaultConfig to any
+// backend without a per-backend override, and trips a backend unhealthy
+// after passiveThreshold consecutive proxied failures (<= 0 disables passive
+// tripping).
+func NewHealthChecker(registry abstractions.Registry, defaultConfig HealthCheckConfig, passiveThreshold int) *HealthChecker {
+	return &HealthChecker{
+		registry:         registry,
+		defaultConfig:    defaultConfig,
+		client:           &http.Client{},
+		passiveThreshold: passiveThreshold,
+		configs:          make(map[string]HealthCheckConfig),
+		states:           make(map[string]*backendState),
+		grpcConn:         make(map[string]*grpc.ClientConn),
+	}
+}
+
+// SetBackendConfig overrides the check configuration for a single backend
+// URL. Call before Start, or while running to change the config on the fly.
+func (h *HealthChecker) SetBackendConfig(url string, cfg HealthCheckConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.configs[url] = cfg
+}
+
+func (h *HealthChecker) configFor(url string) HealthCheckConfig {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cfg, ok := h.configs[url]; ok {
+		return cfg
+	}
+	return h.defaultConfig
+}
+
+// stateFor returns the tracked state for url, seeding a freshly-created
+// one from the registry's current Backend.Health so a backend that starts
+// out unhealthy isn't assumed healthy until its first check result.
+func (h *HealthChecker) stateFor(url string) *backendState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.states[url]
+	if !ok {
+		st = &backendState{healthy: h.registryHealth(url)}
+		h.states[url] = st
+	}
+	return st
+}
+
+func (h *HealthChecker) registryHealth(url string) bool {
+	for _, b := range h.registry.ListBackends() {
+		if b.URL == url {
+			return b.Health
+		}
+	}
+	return true
+}
+
+// Start launches one goroutine per currently-registered backend, each
+// ticking at its own Interval until ctx is cancelled or Stop is called.
+// Backends registered after Start is called are not picked up. Calling
+// Start again launches a second goroutine for every backend already being
+// checked, and Stop then cancels only the latest Start's goroutines.
+func (h *HealthChecker) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	h.cancel = cancel
+	for _, b := range h.registry.ListBackends() {
+		backend := b
+		go h.run(ctx, backend.URL)
+	}
+}
+
+// Stop cancels all running check goroutines.
+func (h *HealthChecker) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+}
+
+func (h *HealthChecker) run(ctx context.Context, url string) {
+	timer := time.NewTimer(h.nextInterval(url))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			h.checkOnce(url)
+			timer.Reset(h.nextInterval(url))
+		}
+	}
+}
+
+// nextInterval returns url's next check delay: cfg.Interval while healthy,
+// or the backend's current quarantine backoff while it isn't, so a
+// persistently failing backend gets re-probed less often instead of at the
+// same cadence as a healthy one.
+func (h *HealthChecker) nextInterval(url string) time.Duration {
+	cfg := h.configFor(url)
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultHealthCheckConfig().Interval
+	}
+	st := h.stateFor(url)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.healthy || st.quarantineInterval <= 0 {
+		return interval
+	}
+	return st.quarantineInterval
+}
+
+// growQuarantine doubles cur (seeding it from cfg.Interval if this is the
+// first failure), capped at cfg.MaxQuarantineInterval. Must be called with
+// the owning backendState's mu held.
+func growQuarantine(cfg HealthCheckConfig, cur time.Duration) time.Duration {
+	base := cfg.Interval
+	if base <= 0 {
+		base = DefaultHealthCheckConfig().Interval
+	}
+	max := cfg.MaxQuarantineInterval
+	if max <= 0 {
+		max = 10 * base
+	}
+	if cur <= 0 {
+		cur = base
+	} else {
+		cur *= 2
+	}
+	if cur > max {
+		cur = max
+	}
+	return cur
+}
+
+// checkOnce runs a single active check for url and applies threshold
+// hysteresis to decide whether Backend.Health should flip.
+func (h *HealthChecker) checkOnce(url string) {
+	cfg := h.configFor(url)
+	st := h.stateFor(url)
+
+	err := h.probe(url, cfg)
+
+	st.mu.Lock()
+	st.lastCheck = time.Now()
+	st.lastErr = err
+	if err == nil {
+		st.consecutivePass++
+		st.consecutiveFail = 0
+		if !st.healthy && st.consecutivePass >= cfg.HealthyThreshold {
+			st.healthy = true
+			st.quarantineInterval = 0
+			h.registry.SetHealth(url, true)
+			defer h.publishHealthChanged(url, true, "active check passed")
+		}
+	} else {
+		st.consecutiveFail++
+		st.consecutivePass = 0
+		if st.healthy && st.consecutiveFail >= cfg.UnhealthyThreshold {
+			st.healthy = false
+			h.registry.SetHealth(url, false)
+			defer h.publishHealthChanged(url, false, "active check failed")
+		}
+		if !st.healthy {
+			st.quarantineInterval = growQuarantine(cfg, st.quarantineInterval)
+		}
+	}
+	st.mu.Unlock()
+}
+
+// probe performs the actual check and returns nil on success or the reason
+// it failed.
+func (h *HealthChecker) probe(url string, cfg HealthCheckConfig) error {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckConfig().Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch cfg.Mode {
+	case CheckModeGRPC:
+		return h.probeGRPC(ctx, url)
+	case CheckModeHTTP, "":
+		return h.probeHTTP(ctx, url, cfg)
+	default:
+		return fmt.Errorf("health_checker: unknown check mode %q for backend %s", cfg.Mode, url)
+	}
+}
+
+func (h *HealthChecker) probeHTTP(ctx context.Context, url string, cfg HealthCheckConfig) error {
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url+cfg.Path, nil)
+	if err != nil {
+		return err
+	}
+	client := h.client
+	if cfg.TLSConfig != nil {
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: cfg.TLSConfig}}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health_checker: request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	min := cfg.MinStatus
+	if min == 0 {
+		min = 200
+	}
+	if resp.StatusCode < min || (cfg.MaxStatus > 0 && resp.StatusCode > cfg.MaxStatus) {
+		return fmt.Errorf("health_checker: %s returned status %d outside [%d,%d]", url, resp.StatusCode, min, cfg.MaxStatus)
+	}
+	if cfg.BodyRegex != nil {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("health_checker: reading body from %s: %w", url, err)
+		}
+		if !cfg.BodyRegex.Match(body) {
+			return fmt.Errorf("health_checker: %s response body did not match %s", url, cfg.BodyRegex.String())
+		}
+	}
+	return nil
+}
+
+func (h *HealthChecker) grpcConnFor(target string) (*grpc.ClientConn, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if conn, ok := h.grpcConn[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	h.grpcConn[target] = conn
+	return conn, nil
+}
+
+func (h *HealthChecker) probeGRPC(ctx context.Context, url string) error {
+	conn, err := h.grpcConnFor(url)
+	if err != nil {
+		return fmt.Errorf("health_checker: dialing %s: %w", url, err)
+	}
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("health_checker: grpc health check on %s failed: %w", url, err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health_checker: %s reported status %s", url, resp.Status)
+	}
+	return nil
+}
+
+// RecordProxyResult is a passive signal from the proxy path: success=false
+// for a 5xx response or a connection error. After passiveThreshold
+// consecutive failures the backend is tripped unhealthy immediately, without
+// waiting for the next active check; any success resets the counter.
+func (h *HealthChecker) RecordProxyResult(url string, success bool) {
+	if h.passiveThreshold <= 0 {
+		return
+	}
+	st := h.stateFor(url)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.lastCheck = time.Now()
+	if success {
+		st.consecutivePassive = 0
+		return
+	}
+	st.consecutivePassive++
+	if st.healthy && st.consecutivePassive >= h.passiveThreshold {
+		st.healthy = false
+		st.lastErr = fmt.Errorf("health_checker: %d consecutive proxied failures", st.consecutivePassive)
+		h.registry.SetHealth(url, false)
+		defer h.publishHealthChanged(url, false, "consecutive proxied failures")
+	}
+}
+
+// SetLatencyThreshold enables RecordProxyLatency's passive trip once
+// passiveThreshold samples in a row exceed thresholdMs; <= 0 disables it
+// (the default).
+func (h *HealthChecker) SetLatencyThreshold(thresholdMs float64) {
+	h.mu.Lock()
+	h.latencyThresholdMs = thresholdMs
+	h.mu.Unlock()
+}
+
+// RecordProxyLatency is a passive signal from the proxy path reporting a
+// single attempt's observed latency. After passiveThreshold consecutive
+// samples above the configured latency threshold, the backend is tripped
+// unhealthy the same way RecordProxyResult trips it on sustained
+// 5xx/connection failures. A no-op until SetLatencyThreshold sets a
+// positive threshold.
+func (h *HealthChecker) RecordProxyLatency(url string, latencyMs float64) {
+	h.mu.Lock()
+	threshold := h.latencyThresholdMs
+	h.mu.Unlock()
+	if threshold <= 0 || h.passiveThreshold <= 0 {
+		return
+	}
+	st := h.stateFor(url)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.lastCheck = time.Now()
+	if latencyMs <= threshold {
+		st.consecutiveLatency = 0
+		return
+	}
+	st.consecutiveLatency++
+	if st.healthy && st.consecutiveLatency >= h.passiveThreshold {
+		st.healthy = false
+		st.lastErr = fmt.Errorf("health_checker: %d consecutive latency samples above %.0fms", st.consecutiveLatency, threshold)
+		h.registry.SetHealth(url, false)
+		defer h.publishHealthChanged(url, false, "sustained high latency")
+	}
+}
+
+// Status returns a snapshot of every backend HealthChecker has observed, for
+// the management mux's /healthz endpoint.
+func (h *HealthChecker) Status() []BackendHealth {
+	h.mu.Lock()
+	urls := make([]string, 0, len(h.states))
+	for url := range h.states {
+		urls = append(urls, url)
+	}
+	h.mu.Unlock()
+
+	out := make([]BackendHealth, 0, len(urls))
+	for _, url := range urls {
+		st := h.stateFor(url)
+		st.mu.Lock()
+		reason := ""
+		if st.lastErr != nil {
+			reason = st.lastErr.Error()
+		}
+		out = append(out, BackendHealth{URL: url, Healthy: st.healthy, LastCheck: st.lastCheck, Reason: reason})
+		st.mu.Unlock()
+	}
+	return out
+}
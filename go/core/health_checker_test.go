@@ -0,0 +1,89 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"openprequal/abstractions"
+	"openprequal/contracts"
+)
+
+func TestHealthCheckerCheckOnceHTTPSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	registry := abstractions.NewInMemoryRegistry()
+	registry.Register(contracts.Backend{URL: srv.URL, Health: false})
+
+	cfg := DefaultHealthCheckConfig()
+	cfg.HealthyThreshold = 1
+	hc := NewHealthChecker(registry, cfg, 0)
+
+	hc.checkOnce(srv.URL)
+
+	list := registry.ListBackends()
+	if len(list) != 1 || !list[0].Health {
+		t.Fatalf("expected backend to be marked healthy, got %+v", list)
+	}
+}
+
+func TestHealthCheckerCheckOnceHTTPFailureRespectsThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	registry := abstractions.NewInMemoryRegistry()
+	registry.Register(contracts.Backend{URL: srv.URL, Health: true})
+
+	cfg := DefaultHealthCheckConfig()
+	cfg.UnhealthyThreshold = 2
+	hc := NewHealthChecker(registry, cfg, 0)
+
+	hc.checkOnce(srv.URL)
+	if !registry.ListBackends()[0].Health {
+		t.Fatalf("expected backend to stay healthy after a single failure")
+	}
+
+	hc.checkOnce(srv.URL)
+	if registry.ListBackends()[0].Health {
+		t.Fatalf("expected backend to be marked unhealthy after reaching UnhealthyThreshold")
+	}
+}
+
+func TestHealthCheckerRecordProxyResultTripsPassively(t *testing.T) {
+	registry := abstractions.NewInMemoryRegistry()
+	registry.Register(contracts.Backend{URL: "http://backend", Health: true})
+
+	hc := NewHealthChecker(registry, DefaultHealthCheckConfig(), 2)
+
+	hc.RecordProxyResult("http://backend", false)
+	if !registry.ListBackends()[0].Health {
+		t.Fatalf("expected backend to stay healthy after a single proxied failure")
+	}
+
+	hc.RecordProxyResult("http://backend", false)
+	if registry.ListBackends()[0].Health {
+		t.Fatalf("expected backend to be tripped unhealthy after passiveThreshold failures")
+	}
+}
+
+func TestHealthCheckerStatus(t *testing.T) {
+	registry := abstractions.NewInMemoryRegistry()
+	registry.Register(contracts.Backend{URL: "http://backend", Health: true})
+	hc := NewHealthChecker(registry, DefaultHealthCheckConfig(), 1)
+
+	hc.RecordProxyResult("http://backend", false)
+
+	status := hc.Status()
+	if len(status) != 1 || status[0].URL != "http://backend" || status[0].Healthy {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if status[0].LastCheck.After(time.Now()) {
+		t.Fatalf("expected LastCheck to be in the past")
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"openprequal/contracts"
+)
+
+// HTTPProber probes a backend's /probe endpoint and decodes the
+// contracts.ProbeResponse JSON body served by cmd/server.
+type HTTPProber struct {
+	client *http.Client
+	path   string
+}
+
+// NewHTTPProber creates an HTTPProber that GETs path (e.g. "/probe") on each
+// backend with the given timeout.
+func NewHTTPProber(path string, timeout time.Duration) *HTTPProber {
+	return &HTTPProber{client: &http.Client{Timeout: timeout}, path: path}
+}
+
+func (h *HTTPProber) Probe(backend contracts.Backend) (float64, float64, error) {
+	resp, err := h.client.Get(backend.URL + h.path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("http_prober: request to %s failed: %w", backend.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("http_prober: %s returned status %d", backend.URL, resp.StatusCode)
+	}
+
+	var out contracts.ProbeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, 0, fmt.Errorf("http_prober: decoding response from %s: %w", backend.URL, err)
+	}
+	return float64(out.InFlightRequests), out.AvgLatency, nil
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"openprequal/contracts"
+)
+
+func TestHTTPProberParsesProbeResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(contracts.ProbeResponse{Status: "ok", InFlightRequests: 3, AvgLatency: 12.5})
+	}))
+	defer srv.Close()
+
+	prober := NewHTTPProber("/probe", time.Second)
+	rif, latencyMS, err := prober.Probe(contracts.Backend{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rif != 3 || latencyMS != 12.5 {
+		t.Fatalf("unexpected probe result: rif=%v latencyMS=%v", rif, latencyMS)
+	}
+}
+
+func TestHTTPProberReturnsErrorOnNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	prober := NewHTTPProber("/probe", time.Second)
+	if _, _, err := prober.Probe(contracts.Backend{URL: srv.URL}); err == nil {
+		t.Fatalf("expected an error for a non-200 response")
+	}
+}
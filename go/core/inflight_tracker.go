@@ -0,0 +1,59 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// InflightTracker counts requests currently being proxied to each backend,
+// keyed by URL. Backend.InFlightRequests only updates when that backend's
+// own heartbeat arrives, which can be stale by seconds; InflightTracker
+// instead increments the instant ProxyHandler dispatches a request and
+// decrements the instant it returns, so selection policies that consult it
+// (e.g. LeastRIF) see the proxy's own live view of load.
+type InflightTracker struct {
+	mu       sync.RWMutex
+	counters map[string]*atomic.Int64
+}
+
+// NewInflightTracker creates an empty tracker.
+func NewInflightTracker() *InflightTracker {
+	return &InflightTracker{counters: make(map[string]*atomic.Int64)}
+}
+
+func (t *InflightTracker) counter(url string) *atomic.Int64 {
+	t.mu.RLock()
+	c, ok := t.counters[url]
+	t.mu.RUnlock()
+	if ok {
+		return c
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok = t.counters[url]; ok {
+		return c
+	}
+	c = &atomic.Int64{}
+	t.counters[url] = c
+	return c
+}
+
+// InFlight returns the current in-flight count for url, or 0 if url has
+// never been tracked.
+func (t *InflightTracker) InFlight(url string) int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if c, ok := t.counters[url]; ok {
+		return c.Load()
+	}
+	return 0
+}
+
+// Begin increments url's in-flight count and returns a func that
+// decrements it; callers should defer the returned func around the
+// dispatch it's tracking.
+func (t *InflightTracker) Begin(url string) func() {
+	c := t.counter(url)
+	c.Add(1)
+	return func() { c.Add(-1) }
+}
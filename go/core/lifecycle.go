@@ -0,0 +1,95 @@
+package core
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// EngineState enumerates the lifecycle states shared by ProbeManager and
+// Prequal, letting an operator pause or drain probing without leaking the
+// background goroutine or losing the accumulated probe pool.
+type EngineState int32
+
+const (
+	StateNew EngineState = iota
+	StateRunning
+	StatePaused
+	StateDraining
+	StateStopped
+)
+
+func (s EngineState) String() string {
+	switch s {
+	case StateNew:
+		return "new"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrInvalidTransition is returned by a lifecycle method called from a state
+// that doesn't support it, e.g. Pause on an engine that was never Started.
+type ErrInvalidTransition struct {
+	From EngineState
+	To   EngineState
+}
+
+func (e *ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("core: invalid lifecycle transition from %s to %s", e.From, e.To)
+}
+
+// Lifecycle is embedded by ProbeManager and Prequal to give both the same
+// atomic, CAS-guarded New/Running/Paused/Draining/Stopped state machine
+// instead of each maintaining its own ad-hoc cancel/stop channel.
+type Lifecycle struct {
+	state int32
+}
+
+// State returns the current state.
+func (l *Lifecycle) State() EngineState {
+	return EngineState(atomic.LoadInt32(&l.state))
+}
+
+// Transition CASes the current state from one of from into to, returning
+// *ErrInvalidTransition if the current state isn't one of from. Exported so
+// embedders outside this package (e.g. algorithms.Prequal) can drive their
+// own Start/Pause/Resume/Drain/Stop methods off the same state machine.
+func (l *Lifecycle) Transition(to EngineState, from ...EngineState) error {
+	for _, f := range from {
+		if atomic.CompareAndSwapInt32(&l.state, int32(f), int32(to)) {
+			return nil
+		}
+	}
+	return &ErrInvalidTransition{From: l.State(), To: to}
+}
+
+// WaitFor polls fn every pollInterval until it returns true or deadline
+// elapses, returning fn's final result. It's used by Drain implementations
+// that wait for an in-flight queue to empty under a caller-supplied deadline.
+func WaitFor(deadline time.Duration, pollInterval time.Duration, fn func() bool) bool {
+	if fn() {
+		return true
+	}
+	timeout := time.After(deadline)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-timeout:
+			return fn()
+		case <-ticker.C:
+			if fn() {
+				return true
+			}
+		}
+	}
+}
@@ -6,10 +6,14 @@ import (
 )
 
 type MetricsManager struct {
-	mu           sync.Mutex
-	inFlight     int
-	rifLatencies map[int][]float64
-	rifBins      []int
+	mu             sync.Mutex
+	inFlight       int
+	rifLatencies   map[int][]float64
+	rifBins        []int
+	retryAttempts  int
+	retrySuccesses int
+	hedgeWins      int
+	hedgeSuccesses int
 }
 
 func NewMetricsManager(rifBins []int) *MetricsManager {
@@ -31,6 +35,45 @@ func (m *MetricsManager) DecInFlight() {
 }
 func (m *MetricsManager) GetInFlight() int { m.mu.Lock(); v := m.inFlight; m.mu.Unlock(); return v }
 
+// RecordRetryAttempt tallies one proxy retry attempt (the initial try is not
+// an attempt) so RetryPolicy usage is observable without a dedicated metrics
+// backend.
+func (m *MetricsManager) RecordRetryAttempt(success bool) {
+	m.mu.Lock()
+	m.retryAttempts++
+	if success {
+		m.retrySuccesses++
+	}
+	m.mu.Unlock()
+}
+
+// GetRetryStats returns the total retry attempts recorded and how many of
+// them ultimately succeeded.
+func (m *MetricsManager) GetRetryStats() (attempts int, successes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.retryAttempts, m.retrySuccesses
+}
+
+// RecordHedgeAttempt tallies one request hedged by RetryExecutor whose
+// secondary (not primary) attempt won the race.
+func (m *MetricsManager) RecordHedgeAttempt(success bool) {
+	m.mu.Lock()
+	m.hedgeWins++
+	if success {
+		m.hedgeSuccesses++
+	}
+	m.mu.Unlock()
+}
+
+// GetHedgeStats returns how many requests a hedged secondary attempt won,
+// and how many of those were ultimately successful.
+func (m *MetricsManager) GetHedgeStats() (wins int, successes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hedgeWins, m.hedgeSuccesses
+}
+
 func (m *MetricsManager) observeLatency(rif int, latency float64) {
 	key := rif
 	if len(m.rifBins) > 0 {
@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"openprequal/contracts"
+)
+
+// Prober probes backends over PrequalProbe.StreamMetrics instead of polling
+// an HTTP endpoint: it dials each backend once and keeps its stream open,
+// so repeated Probe calls just read the latest pushed snapshot rather than
+// paying a connection setup cost per probe.
+type Prober struct {
+	mu      sync.Mutex
+	streams map[string]*probeStream
+}
+
+// NewProber creates a Prober with no open streams; they are established
+// lazily on first use per backend URL.
+func NewProber() *Prober {
+	return &Prober{streams: make(map[string]*probeStream)}
+}
+
+type probeStream struct {
+	conn   *grpc.ClientConn
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	latest Metrics
+	err    error
+}
+
+func (p *Prober) Probe(backend contracts.Backend) (float64, float64, error) {
+	st, err := p.streamFor(backend.URL)
+	if err != nil {
+		return 0, 0, err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.err != nil {
+		return 0, 0, st.err
+	}
+	return st.latest.RIF, st.latest.AvgLatencyMS, nil
+}
+
+func (p *Prober) streamFor(url string) (*probeStream, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if st, ok := p.streams[url]; ok {
+		return st, nil
+	}
+
+	conn, err := grpc.NewClient(url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(JSONContentSubtype)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("probe/grpc: dialing %s: %w", url, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := NewPrequalProbeClient(conn).StreamMetrics(ctx, &PrequalProbeRequest{Url: url})
+	if err != nil {
+		cancel()
+		conn.Close()
+		return nil, fmt.Errorf("probe/grpc: opening stream to %s: %w", url, err)
+	}
+
+	st := &probeStream{conn: conn, cancel: cancel}
+	p.streams[url] = st
+	go st.recvLoop(stream)
+	return st, nil
+}
+
+// recvLoop keeps the single persistent stream for a backend drained,
+// caching the most recent snapshot (or the error that ended the stream) for
+// Probe to read without blocking.
+func (st *probeStream) recvLoop(stream PrequalProbe_StreamMetricsClient) {
+	for {
+		m, err := stream.Recv()
+		st.mu.Lock()
+		if err != nil {
+			st.err = err
+			st.mu.Unlock()
+			return
+		}
+		st.latest = Metrics{
+			RIF:               m.Rif,
+			AvgLatencyMS:      m.AvgLatencyMs,
+			RIFKeyedLatencyMS: m.RifKeyedLatencyMs,
+			CPULoad:           m.CpuLoad,
+		}
+		st.mu.Unlock()
+	}
+}
+
+// Close tears down every open stream and connection.
+func (p *Prober) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var firstErr error
+	for url, st := range p.streams {
+		st.cancel()
+		if err := st.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(p.streams, url)
+	}
+	return firstErr
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"openprequal/contracts"
+)
+
+func TestProberCloseWithNoOpenStreamsIsANoOp(t *testing.T) {
+	p := NewProber()
+	if err := p.Close(); err != nil {
+		t.Fatalf("expected Close on a fresh Prober to be a no-op, got %v", err)
+	}
+}
+
+func TestProberProbeSurfacesStreamSetupErrors(t *testing.T) {
+	p := NewProber()
+	defer p.Close()
+	if _, _, err := p.Probe(contracts.Backend{URL: "127.0.0.1:0"}); err == nil {
+		t.Fatalf("expected an error opening a probe stream to an unserved address")
+	}
+}
+
+// TestProberReceivesStreamedMetricsFromLiveServer drives a real
+// PrequalProbe.StreamMetrics round trip over a live server/client pair,
+// catching wire-format regressions (e.g. the wire types failing to marshal
+// under grpc-go's codec) that a dial-failure-only test can't.
+func TestProberReceivesStreamedMetricsFromLiveServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := NewServer(stubSource{healthy: true}, 10*time.Millisecond)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	p := NewProber()
+	defer p.Close()
+
+	backend := contracts.Backend{URL: lis.Addr().String()}
+	deadline := time.Now().Add(2 * time.Second)
+	var rif, avgLatency float64
+	for {
+		rif, avgLatency, err = p.Probe(backend)
+		// The stream's first snapshot hasn't necessarily arrived yet, in
+		// which case Probe reports the zero value with no error; keep
+		// polling until a real snapshot (RIF 1, per stubSource) lands.
+		if err == nil && rif != 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a streamed metrics snapshot before the deadline, last rif=%v err=%v", rif, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rif != 1 {
+		t.Fatalf("expected RIF 1 (stubSource.Metrics()), got %v", rif)
+	}
+	if avgLatency != 0 {
+		t.Fatalf("expected AvgLatencyMs 0 (stubSource.Metrics()), got %v", avgLatency)
+	}
+}
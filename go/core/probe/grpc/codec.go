@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"encoding/json"
+
+	grpcencoding "google.golang.org/grpc/encoding"
+)
+
+// jsonCodec marshals the PrequalProbe service's wire types as JSON instead of
+// binary protobuf. PrequalProbeRequest/PrequalProbeMetrics in
+// prequalprobe.pb.go are plain structs generated by hand rather than by
+// protoc-gen-go — they carry `protobuf:` tags for documentation but don't
+// implement proto.Message, so grpc-go's built-in "proto" codec (which
+// type-asserts every message to proto.Message) fails on the first call. It's
+// registered under its own content-subtype name, "json", rather than
+// overriding the default "proto" codec, so the genuinely-generated
+// grpc.health.v1 service this package's Server also exposes keeps speaking
+// real binary protobuf to external health checkers (Kubernetes, Consul,
+// etc.). Callers opt in with grpc.CallContentSubtype(JSONContentSubtype) on
+// the ClientConn they use to reach PrequalProbe; see Prober.streamFor.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string { return JSONContentSubtype }
+
+// JSONContentSubtype is the content-subtype callers must request (via
+// grpc.CallContentSubtype) to reach PrequalProbe, whose wire types are
+// marshaled by jsonCodec instead of grpc-go's default proto codec.
+const JSONContentSubtype = "json"
+
+func init() {
+	grpcencoding.RegisterCodec(jsonCodec{})
+}
@@ -0,0 +1,23 @@
+// Hand-written to mirror the message shapes declared in
+// prequalprobe.proto. These structs carry `protobuf:` tags for
+// documentation only — they don't implement proto.Message (no
+// Reset/String/ProtoReflect), so they can't be produced by protoc-gen-go
+// and must not be regenerated from the .proto file; edit them directly
+// instead. They're transported over grpc-go via jsonCodec (see codec.go),
+// not binary protobuf.
+
+package grpc
+
+// PrequalProbeRequest identifies the backend whose load signal is being
+// streamed.
+type PrequalProbeRequest struct {
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+// PrequalProbeMetrics is a single load snapshot pushed by StreamMetrics.
+type PrequalProbeMetrics struct {
+	Rif               float64 `protobuf:"fixed64,1,opt,name=rif,proto3" json:"rif,omitempty"`
+	AvgLatencyMs      float64 `protobuf:"fixed64,2,opt,name=avg_latency_ms,json=avgLatencyMs,proto3" json:"avg_latency_ms,omitempty"`
+	RifKeyedLatencyMs float64 `protobuf:"fixed64,3,opt,name=rif_keyed_latency_ms,json=rifKeyedLatencyMs,proto3" json:"rif_keyed_latency_ms,omitempty"`
+	CpuLoad           float64 `protobuf:"fixed64,4,opt,name=cpu_load,json=cpuLoad,proto3" json:"cpu_load,omitempty"`
+}
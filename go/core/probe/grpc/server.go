@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultStreamInterval is how often StreamMetrics pushes a snapshot when a
+// Server is built with NewServer.
+const DefaultStreamInterval = 2 * time.Second
+
+// Metrics is a single load snapshot reported by a backend.
+type Metrics struct {
+	RIF               float64
+	AvgLatencyMS      float64
+	RIFKeyedLatencyMS float64
+	CPULoad           float64
+}
+
+// Source supplies the data a Server reports: whether the backend is healthy
+// (for grpc.health.v1.Health) and its current load signal (for
+// PrequalProbe.StreamMetrics).
+type Source interface {
+	Healthy() bool
+	Metrics() Metrics
+}
+
+// Server bundles a grpc.health.v1.Health implementation with the
+// OpenPrequal-specific PrequalProbe service, so a backend (or the proxy
+// itself) can expose both over one gRPC listener.
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// NewServer builds a Server backed by source, pushing a PrequalProbeMetrics
+// snapshot every interval (DefaultStreamInterval if <= 0) to each open
+// StreamMetrics call.
+func NewServer(source Source, interval time.Duration) *Server {
+	if interval <= 0 {
+		interval = DefaultStreamInterval
+	}
+	gs := grpc.NewServer()
+	hs := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(gs, hs)
+	RegisterPrequalProbeServer(gs, &prequalProbeServer{source: source, interval: interval})
+
+	s := &Server{Server: gs, health: hs}
+	s.SetHealthy(source.Healthy())
+	return s
+}
+
+// SetHealthy updates the status grpc.health.v1.Health reports for this
+// server's only service (the empty "" name, i.e. overall server health).
+func (s *Server) SetHealthy(healthy bool) {
+	status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if healthy {
+		status = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", status)
+}
+
+type prequalProbeServer struct {
+	UnimplementedPrequalProbeServer
+	source   Source
+	interval time.Duration
+}
+
+func (p *prequalProbeServer) StreamMetrics(req *PrequalProbeRequest, stream PrequalProbe_StreamMetricsServer) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		m := p.source.Metrics()
+		if err := stream.Send(&PrequalProbeMetrics{
+			Rif:               m.RIF,
+			AvgLatencyMs:      m.AvgLatencyMS,
+			RifKeyedLatencyMs: m.RIFKeyedLatencyMS,
+			CpuLoad:           m.CPULoad,
+		}); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewProxyHealthServer builds a grpc.Server exposing only grpc.health.v1.Health,
+// always reporting SERVING, for the proxy's own management port so external
+// orchestrators (Kubernetes gRPC probes, Consul, etc.) can check the proxy
+// itself with a standard protocol instead of parsing /metrics.
+func NewProxyHealthServer() *grpc.Server {
+	gs := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(gs, hs)
+	return gs
+}
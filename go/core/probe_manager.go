@@ -2,22 +2,54 @@ package core
 
 import (
 	"context"
+	"log"
+	"sync/atomic"
 	"time"
+
+	"openprequal/abstractions"
+	"openprequal/contracts"
 )
 
+// defaultProbeScheme is used for any backend whose ProbeScheme is unset.
+const defaultProbeScheme = "http"
+
 type ProbeManager struct {
+	Lifecycle
+
 	pool          *ProbePool
 	queue         *ProbeTaskQueue
 	endpoint      string
 	maxConcurrent int
 	cancel        context.CancelFunc
+	paused        int32 // 1 while Paused; checked by the poll loop between tasks
+
+	// registry and probers are optional; when both are set, Run performs a
+	// real probe (picking a Prober by the task's backend.ProbeScheme)
+	// instead of the simulated placeholder values below.
+	registry abstractions.Registry
+	probers  map[string]Prober
 }
 
 func NewProbeManager(pool *ProbePool, queue *ProbeTaskQueue, endpoint string, maxConcurrent int) *ProbeManager {
 	return &ProbeManager{pool: pool, queue: queue, endpoint: endpoint, maxConcurrent: maxConcurrent}
 }
 
-func (p *ProbeManager) Run(ctx context.Context) {
+// SetProbers makes Run perform real probes: registry resolves a queued
+// backend URL to its contracts.Backend so ProbeScheme can be read, and
+// probers maps each supported scheme (e.g. "http", "grpc") to the Prober
+// that handles it. A task whose scheme has no entry falls back to "http".
+func (p *ProbeManager) SetProbers(registry abstractions.Registry, probers map[string]Prober) {
+	p.registry = registry
+	p.probers = probers
+}
+
+// Start transitions the manager from New or Stopped into Running and begins
+// the background loop that pulls tasks off queue and probes them. Calling
+// Start again from Running, Paused, or Draining is an error.
+func (p *ProbeManager) Start(ctx context.Context) error {
+	if err := p.Transition(StateRunning, StateNew, StateStopped); err != nil {
+		return err
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	p.cancel = cancel
 	go func() {
@@ -26,20 +58,111 @@ func (p *ProbeManager) Run(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			default:
+				if atomic.LoadInt32(&p.paused) == 1 {
+					time.Sleep(100 * time.Millisecond)
+					continue
+				}
 				id := p.queue.GetTask()
 				if id == nil {
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
-				// in full implementation we'd perform HTTP probe; here we simulate
-				p.pool.AddProbe(*id, 0.01, 0.0)
+				p.probeOne(*id)
 			}
 		}
 	}()
+	return nil
+}
+
+// Run is a Start that discards the possible error, kept for callers that
+// only ever start a fresh ProbeManager once.
+func (p *ProbeManager) Run(ctx context.Context) {
+	if err := p.Start(ctx); err != nil {
+		log.Printf("probe_manager: Start: %v", err)
+	}
 }
 
-func (p *ProbeManager) Stop() {
+// Pause stops the loop from picking up new tasks without tearing down its
+// goroutine or discarding the pool, so Next (via the pool it reads) keeps
+// selecting among the last samples recorded.
+func (p *ProbeManager) Pause() error {
+	if err := p.Transition(StatePaused, StateRunning); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.paused, 1)
+	return nil
+}
+
+// Resume undoes Pause, letting the loop resume pulling tasks off queue.
+func (p *ProbeManager) Resume() error {
+	if err := p.Transition(StateRunning, StatePaused); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.paused, 0)
+	return nil
+}
+
+// Drain lets the loop keep working through queue (resuming it first if
+// Paused) and blocks until queue empties or deadline elapses, so an
+// in-flight probe round finishes instead of being abandoned mid-flight;
+// tasks queued during the wait are probed too. It then stops the loop from
+// picking up further tasks (like Pause) but does not stop the goroutine;
+// call Stop afterward to do that.
+func (p *ProbeManager) Drain(deadline time.Duration) error {
+	if err := p.Transition(StateDraining, StateRunning, StatePaused); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.paused, 0)
+	WaitFor(deadline, 50*time.Millisecond, func() bool { return p.queue.Size() == 0 })
+	atomic.StoreInt32(&p.paused, 1)
+	return nil
+}
+
+// probeOne performs a single probe round for the backend identified by url,
+// recording the result into the pool. With no registry/probers configured
+// it falls back to the historical simulated values.
+func (p *ProbeManager) probeOne(url string) {
+	if p.registry == nil || len(p.probers) == 0 {
+		// in full implementation we'd perform HTTP probe; here we simulate
+		p.pool.AddProbe(url, 0.01, 0.0)
+		return
+	}
+
+	var backend *contracts.Backend
+	for _, b := range p.registry.ListBackends() {
+		if b.URL == url {
+			backend = &b
+			break
+		}
+	}
+
+	scheme := defaultProbeScheme
+	if backend != nil && backend.ProbeScheme != "" {
+		scheme = backend.ProbeScheme
+	}
+	prober, ok := p.probers[scheme]
+	if !ok {
+		prober, ok = p.probers[defaultProbeScheme]
+	}
+	if !ok || backend == nil {
+		return
+	}
+
+	rif, latencyMS, err := prober.Probe(*backend)
+	if err != nil {
+		log.Printf("probe_manager: probing %s over %s failed: %v", url, scheme, err)
+		return
+	}
+	p.pool.AddProbe(url, latencyMS, rif)
+}
+
+// Stop cancels the background loop, from Running, Paused, or Draining.
+func (p *ProbeManager) Stop() error {
+	if err := p.Transition(StateStopped, StateRunning, StatePaused, StateDraining); err != nil {
+		return err
+	}
 	if p.cancel != nil {
 		p.cancel()
 	}
+	return nil
 }
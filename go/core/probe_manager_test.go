@@ -3,6 +3,10 @@ package core
 import (
 	"context"
 	"testing"
+	"time"
+
+	"openprequal/abstractions"
+	"openprequal/contracts"
 )
 
 func TestProbeManagerRun(t *testing.T) {
@@ -15,3 +19,77 @@ func TestProbeManagerRun(t *testing.T) {
 	// allow some time to process
 	pm.Stop()
 }
+
+// fakeProber records the backends it was asked to probe and returns fixed values.
+type fakeProber struct {
+	rif, latencyMS float64
+	calls          []string
+}
+
+func (f *fakeProber) Probe(backend contracts.Backend) (float64, float64, error) {
+	f.calls = append(f.calls, backend.URL)
+	return f.rif, f.latencyMS, nil
+}
+
+func TestProbeManagerUsesProberForBackendScheme(t *testing.T) {
+	registry := abstractions.NewInMemoryRegistry()
+	registry.Register(contracts.Backend{URL: "http://grpc-backend", ProbeScheme: "grpc"})
+
+	pool := NewProbePool()
+	q := NewProbeTaskQueue()
+	pm := NewProbeManager(pool, q, "", 1)
+
+	httpProber := &fakeProber{rif: 1, latencyMS: 10}
+	grpcProber := &fakeProber{rif: 2, latencyMS: 20}
+	pm.SetProbers(registry, map[string]Prober{"http": httpProber, "grpc": grpcProber})
+
+	pm.probeOne("http://grpc-backend")
+
+	if len(grpcProber.calls) != 1 || len(httpProber.calls) != 0 {
+		t.Fatalf("expected the grpc-scheme backend to use the grpc prober, got http calls=%v grpc calls=%v", httpProber.calls, grpcProber.calls)
+	}
+	if latency := pool.GetCurrentLatency("http://grpc-backend"); latency == nil || *latency != 20 {
+		t.Fatalf("expected recorded latency 20, got %v", latency)
+	}
+}
+
+func TestProbeManagerLifecycleTransitions(t *testing.T) {
+	pm := NewProbeManager(NewProbePool(), NewProbeTaskQueue(), "", 1)
+
+	if err := pm.Pause(); err == nil {
+		t.Fatalf("expected Pause before Start to be an invalid transition")
+	}
+	if err := pm.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if state := pm.State(); state != StateRunning {
+		t.Fatalf("expected Running after Start, got %v", state)
+	}
+	if err := pm.Pause(); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if err := pm.Start(context.Background()); err == nil {
+		t.Fatalf("expected Start while Paused to be an invalid transition")
+	}
+	if err := pm.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if err := pm.Drain(100 * time.Millisecond); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if err := pm.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestProbeManagerFallsBackToSimulatedWithoutProbers(t *testing.T) {
+	pool := NewProbePool()
+	q := NewProbeTaskQueue()
+	pm := NewProbeManager(pool, q, "", 1)
+
+	pm.probeOne("http://unconfigured")
+
+	if latency := pool.GetCurrentLatency("http://unconfigured"); latency == nil {
+		t.Fatalf("expected the legacy simulated probe to still record a value")
+	}
+}
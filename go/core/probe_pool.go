@@ -2,6 +2,9 @@ package core
 
 import (
 	"sync"
+
+	"openprequal/events"
+	"openprequal/metrics"
 )
 
 type ProbeEntry struct {
@@ -13,15 +16,21 @@ type ProbePool struct {
 	mu          sync.Mutex
 	probes      map[string]*ProbeEntry
 	maxBackends int
+	bus         *events.Bus
 }
 
 func NewProbePool() *ProbePool {
 	return &ProbePool{probes: make(map[string]*ProbeEntry), maxBackends: 16}
 }
 
+// SetBus makes AddProbe publish a ProbeCompleted event per call; nil
+// disables publishing (the default).
+func (p *ProbePool) SetBus(bus *events.Bus) {
+	p.bus = bus
+}
+
 func (p *ProbePool) AddProbe(backendID string, latency float64, rif float64) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 	if _, ok := p.probes[backendID]; !ok {
 		if len(p.probes) >= p.maxBackends {
 			// remove arbitrary oldest entry
@@ -35,6 +44,9 @@ func (p *ProbePool) AddProbe(backendID string, latency float64, rif float64) {
 	e := p.probes[backendID]
 	e.Latencies = append(e.Latencies, latency)
 	e.RIFValues = append(e.RIFValues, rif)
+	p.mu.Unlock()
+	metrics.ObserveBackendSample(backendID, rif, latency)
+	p.bus.Publish(events.Event{Kind: events.ProbeCompleted, Backend: backendID, Data: map[string]interface{}{"latency_ms": latency, "rif": rif}})
 }
 
 func (p *ProbePool) GetCurrentLatency(backendID string) *float64 {
@@ -38,3 +38,22 @@ func (q *ProbeTaskQueue) GetTask() *string {
 	delete(q.set, id)
 	return &id
 }
+
+// RemoveTask drops id from the queue if present, reporting whether it was
+// there. Used to cancel a backend's pending probe round, e.g. when the
+// selection policy that scheduled it is told to abandon that workload.
+func (q *ProbeTaskQueue) RemoveTask(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.set[id]; !ok {
+		return false
+	}
+	delete(q.set, id)
+	for i, queued := range q.queue {
+		if queued == id {
+			q.queue = append(q.queue[:i], q.queue[i+1:]...)
+			break
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package core
+
+import "openprequal/contracts"
+
+// Prober performs a single probe round-trip against a backend and reports
+// the load signal observed. ProbeManager picks an implementation per
+// backend from its Backend.ProbeScheme, so HTTP and gRPC targets can be
+// probed interchangeably.
+type Prober interface {
+	// Probe returns the backend's current requests-in-flight and average
+	// latency (ms), or an error if the probe could not be completed.
+	Probe(backend contracts.Backend) (rif float64, latencyMS float64, err error)
+}
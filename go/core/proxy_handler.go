@@ -1,26 +1,88 @@
 package core
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"time"
+
+	"openprequal/contracts"
 )
 
+// ProxyHandler forwards requests to a selected backend through a Transport,
+// so the same handler can front plain HTTP backends or, via
+// FastCGITransport, app servers like PHP-FPM.
 type ProxyHandler struct {
-	client *http.Client
+	transport Transport
+	fastcgi   Transport
+	breakers  *CircuitBreakerManager
+	inflight  *InflightTracker
+}
+
+// NewProxyHandler creates a ProxyHandler whose default Transport is used
+// for backends with TransportKind "http" (or unset). fastcgi, if non-nil,
+// is used for backends with TransportKind "fastcgi".
+func NewProxyHandler(transport Transport, fastcgi Transport) *ProxyHandler {
+	return &ProxyHandler{transport: transport, fastcgi: fastcgi}
 }
 
-func NewProxyHandler(client *http.Client) *ProxyHandler { return &ProxyHandler{client: client} }
+// SetCircuitBreakers wires a CircuitBreakerManager into HandleProxy: once
+// set, every call is gated by the backend's breaker and feeds its outcome
+// back in. A nil manager (the default) disables breaking entirely.
+func (p *ProxyHandler) SetCircuitBreakers(breakers *CircuitBreakerManager) {
+	p.breakers = breakers
+}
+
+// SetInflightTracker wires an InflightTracker into HandleProxy: once set,
+// every call increments the backend's live in-flight counter before
+// dispatch and decrements it afterwards. A nil tracker (the default)
+// disables tracking entirely.
+func (p *ProxyHandler) SetInflightTracker(tracker *InflightTracker) {
+	p.inflight = tracker
+}
 
-func (p *ProxyHandler) HandleProxy(req *http.Request, backendURL string) (*http.Response, error) {
-	if backendURL == "" {
+func (p *ProxyHandler) HandleProxy(req *http.Request, backend *contracts.Backend) (*http.Response, error) {
+	if backend == nil || backend.URL == "" {
 		return &http.Response{StatusCode: 503, Body: io.NopCloser(nil)}, nil
 	}
-	// in full implementation we'd rewrite path and forward headers/body
-	outReq, _ := http.NewRequest(req.Method, backendURL+req.URL.Path, req.Body)
-	outReq.Header = req.Header
-	resp, err := p.client.Do(outReq)
+	if p.breakers != nil {
+		if allowed, err := p.breakers.Allow(backend.URL); err != nil {
+			return nil, err
+		} else if !allowed {
+			return p.breakers.Fallback(backend.URL, req), nil
+		}
+	}
+	transport, err := p.transportFor(backend)
 	if err != nil {
 		return nil, err
 	}
-	return resp, nil
+	if p.inflight != nil {
+		end := p.inflight.Begin(backend.URL)
+		defer end()
+	}
+	start := time.Now()
+	resp, err := transport.RoundTrip(req, backend)
+	if p.breakers != nil {
+		latencyMS := float64(time.Since(start)) / float64(time.Millisecond)
+		if err != nil {
+			p.breakers.RecordResult(backend.URL, 0, latencyMS, true)
+		} else {
+			p.breakers.RecordResult(backend.URL, resp.StatusCode, latencyMS, false)
+		}
+	}
+	return resp, err
+}
+
+func (p *ProxyHandler) transportFor(backend *contracts.Backend) (Transport, error) {
+	switch backend.TransportKind {
+	case "", "http":
+		return p.transport, nil
+	case "fastcgi":
+		if p.fastcgi == nil {
+			return nil, fmt.Errorf("proxy_handler: no FastCGI transport configured for backend %s", backend.URL)
+		}
+		return p.fastcgi, nil
+	default:
+		return nil, fmt.Errorf("proxy_handler: unknown transport kind %q for backend %s", backend.TransportKind, backend.URL)
+	}
 }
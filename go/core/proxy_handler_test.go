@@ -0,0 +1,78 @@
+package core
+
+import (
+	"net/http"
+	"testing"
+
+	"openprequal/contracts"
+)
+
+type fakeTransport struct {
+	called bool
+	resp   *http.Response
+	err    error
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request, backend *contracts.Backend) (*http.Response, error) {
+	f.called = true
+	return f.resp, f.err
+}
+
+func TestProxyHandlerHandleProxyUsesHTTPTransport(t *testing.T) {
+	httpT := &fakeTransport{resp: &http.Response{StatusCode: 200}}
+	p := NewProxyHandler(httpT, nil)
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+	backend := &contracts.Backend{URL: "http://backend"}
+
+	resp, err := p.HandleProxy(req, backend)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !httpT.called {
+		t.Fatalf("expected http transport to be used")
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestProxyHandlerHandleProxyUsesFastCGITransport(t *testing.T) {
+	httpT := &fakeTransport{}
+	fcgiT := &fakeTransport{resp: &http.Response{StatusCode: 200}}
+	p := NewProxyHandler(httpT, fcgiT)
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+	backend := &contracts.Backend{URL: "127.0.0.1:9000", TransportKind: "fastcgi"}
+
+	if _, err := p.HandleProxy(req, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if httpT.called {
+		t.Fatalf("did not expect http transport to be used")
+	}
+	if !fcgiT.called {
+		t.Fatalf("expected fastcgi transport to be used")
+	}
+}
+
+func TestProxyHandlerHandleProxyMissingFastCGITransport(t *testing.T) {
+	p := NewProxyHandler(&fakeTransport{}, nil)
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+	backend := &contracts.Backend{URL: "127.0.0.1:9000", TransportKind: "fastcgi"}
+
+	if _, err := p.HandleProxy(req, backend); err == nil {
+		t.Fatalf("expected error for unconfigured fastcgi transport")
+	}
+}
+
+func TestProxyHandlerHandleProxyNilBackend(t *testing.T) {
+	p := NewProxyHandler(&fakeTransport{}, nil)
+	req, _ := http.NewRequest("GET", "http://proxy/", nil)
+
+	resp, err := p.HandleProxy(req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 503 {
+		t.Fatalf("got status %d, want 503", resp.StatusCode)
+	}
+}
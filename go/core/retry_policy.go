@@ -0,0 +1,273 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"openprequal/contracts"
+)
+
+// RetryPolicy configures how RetryExecutor retries a failed proxied request:
+// which methods/status codes are eligible, and the backoff between
+// attempts. The delay formula is the standard gRPC connection-backoff
+// formulation: min(MaxDelay, BaseDelay * Factor^retries) jittered by ±Jitter.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first
+	// (MaxAttempts<=1 disables retries).
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Factor      float64
+	Jitter      float64
+
+	// StatusCodes lists the HTTP status codes (beyond network errors) that
+	// are eligible for retry, e.g. {502, 503, 504}.
+	StatusCodes map[int]bool
+}
+
+// DefaultRetryPolicy returns the standard gRPC backoff defaults
+// (BaseDelay=1s, Factor=1.6, Jitter=0.2, MaxDelay=120s) with 3 attempts and
+// retries on 502/503/504.
+func DefaultRetryPolicy() RetryPolicy {
+	return NewRetryPolicy(3, time.Second, 120*time.Second, 1.6, 0.2, []int{502, 503, 504})
+}
+
+// NewRetryPolicy builds a RetryPolicy from its primitive fields, the shape
+// it's assembled in from env/config at startup.
+func NewRetryPolicy(maxAttempts int, baseDelay, maxDelay time.Duration, factor, jitter float64, statusCodes []int) RetryPolicy {
+	codes := make(map[int]bool, len(statusCodes))
+	for _, c := range statusCodes {
+		codes[c] = true
+	}
+	return RetryPolicy{MaxAttempts: maxAttempts, BaseDelay: baseDelay, MaxDelay: maxDelay, Factor: factor, Jitter: jitter, StatusCodes: codes}
+}
+
+// isIdempotentMethod returns true for the methods safe to retry without a
+// prior result, per RFC 7231.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// NextDelay returns the backoff before the (retries+1)-th attempt:
+// min(MaxDelay, BaseDelay * Factor^retries) jittered by up to ±Jitter.
+func (p RetryPolicy) NextDelay(retries int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(p.Factor, float64(retries))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// shouldRetry decides whether a failed attempt is eligible for another try:
+// the request method must be idempotent, and the failure must be a network
+// error or one of StatusCodes.
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if !isIdempotentMethod(req.Method) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp != nil && p.StatusCodes[resp.StatusCode]
+}
+
+// BackendSelector picks the next backend to try, given the URLs already
+// attempted this request; it returns nil once no healthy backend remains.
+type BackendSelector func(excluded map[string]bool) *contracts.Backend
+
+// HedgeConfig enables speculative retries on the first attempt: if the
+// primary backend hasn't responded within After, a second request is fired
+// against a different backend (via the same BackendSelector, excluding the
+// primary) and whichever responds first wins; the loser's request context
+// is cancelled so it doesn't keep running past the point its answer matters.
+type HedgeConfig struct {
+	Enabled bool
+	After   time.Duration
+}
+
+// hedgeOutcome carries one racing attempt's result back to executeHedged.
+type hedgeOutcome struct {
+	backend *contracts.Backend
+	resp    *http.Response
+	err     error
+}
+
+// RetryExecutor retries a ProxyHandler call against a fresh backend (chosen
+// by BackendSelector) according to a RetryPolicy, cloning the request body
+// for each attempt and reporting per-attempt outcomes to MetricsManager.
+type RetryExecutor struct {
+	handler *ProxyHandler
+	policy  RetryPolicy
+	metrics *MetricsManager
+
+	// OnAttempt, if set, is called after every attempt (including the
+	// first) with the backend it was sent to, the outcome, and the
+	// attempt's latency in milliseconds, so callers can feed the same
+	// per-backend bookkeeping (e.g. HealthChecker's passive tripping on
+	// errors or on sustained high latency) they'd otherwise do inline
+	// around a single ProxyHandler.HandleProxy call.
+	OnAttempt func(backend *contracts.Backend, resp *http.Response, err error, latencyMS float64)
+
+	// Hedge, if Enabled, races the first attempt against a second backend
+	// once After has elapsed with no response. Zero value disables hedging.
+	Hedge HedgeConfig
+
+	// Probes, if set, receives each attempt's observed latency (and a
+	// zero RIF, since the data plane doesn't see a backend's queue depth),
+	// so real traffic contributes to the same ProbePool the probe loop
+	// feeds Prequal's cold/hot classification from.
+	Probes *ProbePool
+}
+
+// NewRetryExecutor creates a RetryExecutor. metrics may be nil to skip
+// per-attempt reporting.
+func NewRetryExecutor(handler *ProxyHandler, policy RetryPolicy, metrics *MetricsManager) *RetryExecutor {
+	return &RetryExecutor{handler: handler, policy: policy, metrics: metrics}
+}
+
+// Execute proxies req, retrying against a different healthy backend (per
+// selectBackend) on eligible failures until the policy's MaxAttempts is
+// reached or no healthy backend remains.
+func (re *RetryExecutor) Execute(req *http.Request, selectBackend BackendSelector) (*http.Response, error) {
+	bodyBytes, err := bufferBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("retry_policy: buffering request body: %w", err)
+	}
+
+	maxAttempts := re.policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	excluded := make(map[string]bool)
+	var resp *http.Response
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		backend := selectBackend(excluded)
+		if backend == nil {
+			if resp != nil || err != nil {
+				break
+			}
+			return nil, fmt.Errorf("retry_policy: no healthy backend available")
+		}
+		excluded[backend.URL] = true
+
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = cloneRequestWithBody(req, bodyBytes)
+		}
+
+		usedBackend := backend
+		start := time.Now()
+		if attempt == 0 && re.Hedge.Enabled {
+			usedBackend, resp, err = re.executeHedged(attemptReq, backend, selectBackend, excluded, bodyBytes)
+		} else {
+			resp, err = re.handler.HandleProxy(attemptReq, backend)
+		}
+		latencyMS := float64(time.Since(start)) / float64(time.Millisecond)
+		if re.Probes != nil {
+			re.Probes.AddProbe(usedBackend.URL, latencyMS, 0)
+		}
+		if re.OnAttempt != nil {
+			re.OnAttempt(usedBackend, resp, err, latencyMS)
+		}
+		if attempt > 0 && re.metrics != nil {
+			re.metrics.RecordRetryAttempt(err == nil && (resp == nil || resp.StatusCode < 500))
+		}
+
+		if !re.policy.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		if attempt < maxAttempts-1 {
+			time.Sleep(re.policy.NextDelay(attempt))
+		}
+	}
+	return resp, err
+}
+
+// executeHedged races primaryReq against primary with a second request
+// fired at a different backend (via selectBackend, excluding primary and
+// anything already in excluded) once re.Hedge.After elapses with no
+// response. It returns whichever attempt answers first and cancels the
+// other's context so a slow loser stops consuming backend capacity.
+func (re *RetryExecutor) executeHedged(primaryReq *http.Request, primary *contracts.Backend, selectBackend BackendSelector, excluded map[string]bool, bodyBytes []byte) (*contracts.Backend, *http.Response, error) {
+	ctx, cancel := context.WithCancel(primaryReq.Context())
+	defer cancel()
+
+	results := make(chan hedgeOutcome, 2)
+	launch := func(backend *contracts.Backend, req *http.Request) {
+		resp, err := re.handler.HandleProxy(req.WithContext(ctx), backend)
+		results <- hedgeOutcome{backend: backend, resp: resp, err: err}
+	}
+	go launch(primary, primaryReq)
+
+	timer := time.NewTimer(re.Hedge.After)
+	defer timer.Stop()
+
+	secondaryLaunched := false
+	for {
+		select {
+		case out := <-results:
+			cancel()
+			if out.backend != primary && re.metrics != nil {
+				re.metrics.RecordHedgeAttempt(out.err == nil && (out.resp == nil || out.resp.StatusCode < 500))
+			}
+			return out.backend, out.resp, out.err
+		case <-timer.C:
+			if secondaryLaunched {
+				continue
+			}
+			secondaryLaunched = true
+			secondary := selectBackend(excluded)
+			if secondary == nil {
+				continue
+			}
+			excluded[secondary.URL] = true
+			go launch(secondary, cloneRequestWithBody(primaryReq, bodyBytes))
+		}
+	}
+}
+
+// bufferBody drains req.Body (if any) into memory up front so every retry
+// attempt can replay the same bytes, and rewinds req itself so the first
+// attempt still sees the full body.
+func bufferBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// cloneRequestWithBody shallow-clones req for a retry attempt with a fresh
+// reader over bodyBytes, so a previous attempt's (already-consumed) body
+// reader isn't reused.
+func cloneRequestWithBody(req *http.Request, bodyBytes []byte) *http.Request {
+	clone := req.Clone(req.Context())
+	if bodyBytes != nil {
+		clone.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		clone.ContentLength = int64(len(bodyBytes))
+	}
+	return clone
+}
@@ -0,0 +1,258 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"openprequal/contracts"
+)
+
+func TestRetryPolicyNextDelayBacksOffAndCaps(t *testing.T) {
+	p := NewRetryPolicy(5, 10*time.Millisecond, 50*time.Millisecond, 2.0, 0, nil)
+	if got := p.NextDelay(0); got != 10*time.Millisecond {
+		t.Fatalf("expected first delay to equal BaseDelay, got %v", got)
+	}
+	if got := p.NextDelay(1); got != 20*time.Millisecond {
+		t.Fatalf("expected delay to double, got %v", got)
+	}
+	if got := p.NextDelay(10); got != 50*time.Millisecond {
+		t.Fatalf("expected delay to cap at MaxDelay, got %v", got)
+	}
+}
+
+func TestRetryPolicyNextDelayJitterStaysInBounds(t *testing.T) {
+	p := NewRetryPolicy(5, 100*time.Millisecond, time.Second, 1.6, 0.2, nil)
+	for i := 0; i < 20; i++ {
+		d := p.NextDelay(0)
+		if d < 80*time.Millisecond || d > 120*time.Millisecond {
+			t.Fatalf("delay %v outside ±20%% jitter bounds around 100ms", d)
+		}
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy()
+	get, _ := http.NewRequest(http.MethodGet, "http://x", nil)
+	post, _ := http.NewRequest(http.MethodPost, "http://x", nil)
+
+	if !p.shouldRetry(get, nil, errors.New("conn refused")) {
+		t.Fatalf("expected a network error on GET to be retryable")
+	}
+	if p.shouldRetry(post, nil, errors.New("conn refused")) {
+		t.Fatalf("expected a network error on POST to not be retryable")
+	}
+	if !p.shouldRetry(get, &http.Response{StatusCode: 503}, nil) {
+		t.Fatalf("expected a listed status code to be retryable")
+	}
+	if p.shouldRetry(get, &http.Response{StatusCode: 404}, nil) {
+		t.Fatalf("expected an unlisted status code to not be retryable")
+	}
+}
+
+// countingTransport fails the first N calls with a network error, then
+// succeeds, recording which backend URL each call targeted.
+type countingTransport struct {
+	failFirst int
+	calls     []string
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request, backend *contracts.Backend) (*http.Response, error) {
+	c.calls = append(c.calls, backend.URL)
+	if len(c.calls) <= c.failFirst {
+		return nil, errors.New("connection refused")
+	}
+	body, _ := io.ReadAll(req.Body)
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func TestRetryExecutorRetriesAgainstADifferentBackend(t *testing.T) {
+	transport := &countingTransport{failFirst: 1}
+	handler := NewProxyHandler(transport, nil)
+	policy := NewRetryPolicy(3, time.Millisecond, 10*time.Millisecond, 2.0, 0, []int{503})
+	metrics := NewMetricsManager(nil)
+	re := NewRetryExecutor(handler, policy, metrics)
+
+	backends := []*contracts.Backend{{URL: "http://a"}, {URL: "http://b"}}
+	selector := func(excluded map[string]bool) *contracts.Backend {
+		for _, b := range backends {
+			if !excluded[b.URL] {
+				return b
+			}
+		}
+		return nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", bytes.NewBufferString("payload"))
+	resp, err := re.Execute(req, selector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected eventual success, got status %d", resp.StatusCode)
+	}
+	if len(transport.calls) != 2 || transport.calls[0] != "http://a" || transport.calls[1] != "http://b" {
+		t.Fatalf("expected one retry against a different backend, got %v", transport.calls)
+	}
+	if attempts, successes := metrics.GetRetryStats(); attempts != 1 || successes != 1 {
+		t.Fatalf("expected 1 successful retry attempt recorded, got attempts=%d successes=%d", attempts, successes)
+	}
+}
+
+func TestRetryExecutorDoesNotRetryNonIdempotentMethod(t *testing.T) {
+	transport := &countingTransport{failFirst: 5}
+	handler := NewProxyHandler(transport, nil)
+	policy := NewRetryPolicy(3, time.Millisecond, 10*time.Millisecond, 2.0, 0, []int{503})
+	re := NewRetryExecutor(handler, policy, nil)
+
+	backend := &contracts.Backend{URL: "http://a"}
+	selector := func(excluded map[string]bool) *contracts.Backend {
+		if excluded[backend.URL] {
+			return nil
+		}
+		return backend
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://proxy/", nil)
+	_, err := re.Execute(req, selector)
+	if err == nil {
+		t.Fatalf("expected the single failed attempt's error to surface")
+	}
+	if len(transport.calls) != 1 {
+		t.Fatalf("expected exactly one attempt for a non-idempotent method, got %d", len(transport.calls))
+	}
+}
+
+// slowTransport waits for the per-backend delay in delays (or ctx
+// cancellation) before returning a fixed response, recording which backend
+// URL it was called with.
+type slowTransport struct {
+	delays map[string]time.Duration
+	mu     sync.Mutex
+	calls  []string
+}
+
+func (s *slowTransport) RoundTrip(req *http.Request, backend *contracts.Backend) (*http.Response, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, backend.URL)
+	s.mu.Unlock()
+	select {
+	case <-time.After(s.delays[backend.URL]):
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func TestRetryExecutorHedgeFiresSecondaryAfterDelay(t *testing.T) {
+	transport := &slowTransport{delays: map[string]time.Duration{
+		"http://a": 200 * time.Millisecond,
+		"http://b": time.Millisecond,
+	}}
+	handler := NewProxyHandler(transport, nil)
+	policy := NewRetryPolicy(1, time.Millisecond, time.Millisecond, 2.0, 0, nil)
+	re := NewRetryExecutor(handler, policy, NewMetricsManager(nil))
+	re.Hedge = HedgeConfig{Enabled: true, After: 5 * time.Millisecond}
+
+	backends := []*contracts.Backend{{URL: "http://a"}, {URL: "http://b"}}
+	selector := func(excluded map[string]bool) *contracts.Backend {
+		for _, b := range backends {
+			if !excluded[b.URL] {
+				return b
+			}
+		}
+		return nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+	resp, err := re.Execute(req, selector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+	if len(transport.calls) != 2 {
+		t.Fatalf("expected both the primary and a hedged secondary to be called, got %v", transport.calls)
+	}
+	if wins, _ := re.metrics.GetHedgeStats(); wins != 1 {
+		t.Fatalf("expected the secondary to win the hedge race, got %d wins", wins)
+	}
+}
+
+func TestRetryExecutorHedgeDisabledUsesOnlyPrimary(t *testing.T) {
+	transport := &slowTransport{delays: map[string]time.Duration{"http://a": time.Millisecond}}
+	handler := NewProxyHandler(transport, nil)
+	policy := NewRetryPolicy(1, time.Millisecond, time.Millisecond, 2.0, 0, nil)
+	re := NewRetryExecutor(handler, policy, nil)
+
+	backend := &contracts.Backend{URL: "http://a"}
+	selector := func(excluded map[string]bool) *contracts.Backend {
+		if excluded[backend.URL] {
+			return nil
+		}
+		return backend
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+	if _, err := re.Execute(req, selector); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(transport.calls) != 1 {
+		t.Fatalf("expected no hedged secondary call, got %v", transport.calls)
+	}
+}
+
+func TestRetryExecutorFeedsProbePoolFromAttemptLatency(t *testing.T) {
+	transport := &countingTransport{}
+	handler := NewProxyHandler(transport, nil)
+	policy := NewRetryPolicy(1, time.Millisecond, time.Millisecond, 2.0, 0, nil)
+	re := NewRetryExecutor(handler, policy, nil)
+	pool := NewProbePool()
+	re.Probes = pool
+
+	backend := &contracts.Backend{URL: "http://a"}
+	selector := func(excluded map[string]bool) *contracts.Backend {
+		if excluded[backend.URL] {
+			return nil
+		}
+		return backend
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+	if _, err := re.Execute(req, selector); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latency := pool.GetCurrentLatency(backend.URL); latency == nil {
+		t.Fatalf("expected the attempt's latency to be recorded in the probe pool")
+	}
+}
+
+func TestRetryExecutorGivesUpWhenNoHealthyBackendRemains(t *testing.T) {
+	transport := &countingTransport{failFirst: 5}
+	handler := NewProxyHandler(transport, nil)
+	policy := NewRetryPolicy(5, time.Millisecond, 10*time.Millisecond, 2.0, 0, []int{503})
+	re := NewRetryExecutor(handler, policy, nil)
+
+	backend := &contracts.Backend{URL: "http://a"}
+	selector := func(excluded map[string]bool) *contracts.Backend {
+		if excluded[backend.URL] {
+			return nil
+		}
+		return backend
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+	_, err := re.Execute(req, selector)
+	if err == nil {
+		t.Fatalf("expected the last failure to surface once backends are exhausted")
+	}
+	if len(transport.calls) != 1 {
+		t.Fatalf("expected a single attempt once the only backend is excluded, got %d", len(transport.calls))
+	}
+}
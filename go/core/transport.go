@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"openprequal/contracts"
+)
+
+// Transport forwards req to backend and returns its response, letting
+// ProxyHandler speak a protocol other than plain HTTP (e.g. FastCGI)
+// without changing its own logic.
+type Transport interface {
+	RoundTrip(req *http.Request, backend *contracts.Backend) (*http.Response, error)
+}
+
+// HTTPTransportOptions tunes the dialer, TLS settings, and pooling used by
+// an HTTPTransport. Zero values fall back to sensible defaults.
+type HTTPTransportOptions struct {
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	TLSHandshakeTimeout time.Duration
+	TLSConfig           *tls.Config
+
+	// MaxIdleConnsPerHost bounds the pooled idle connections kept open to
+	// each backend, so a hot backend doesn't keep reconnecting under load.
+	MaxIdleConnsPerHost int
+
+	// RequestTimeout bounds a single RoundTrip call via
+	// context.WithTimeout, independent of req's own context/deadline. Zero
+	// disables it, leaving only req's context (if any) in control.
+	RequestTimeout time.Duration
+}
+
+// HTTPTransport forwards requests over plain HTTP(S) using a *http.Client
+// built from HTTPTransportOptions.
+type HTTPTransport struct {
+	client         *http.Client
+	requestTimeout time.Duration
+}
+
+// NewHTTPTransport builds an HTTPTransport, applying defaults for any zero
+// fields in opts (5s dial timeout, 30s keepalive, 5s TLS handshake timeout,
+// 32 idle conns/host).
+func NewHTTPTransport(opts HTTPTransportOptions) *HTTPTransport {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = 5 * time.Second
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = 32
+	}
+	dialer := &net.Dialer{Timeout: opts.DialTimeout, KeepAlive: opts.KeepAlive}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: opts.TLSHandshakeTimeout,
+		TLSClientConfig:     opts.TLSConfig,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+	}
+	return &HTTPTransport{client: &http.Client{Transport: transport}, requestTimeout: opts.RequestTimeout}
+}
+
+// RoundTrip rewrites req's URL to point at backend.URL and forwards it,
+// bounding the call with RequestTimeout (if configured) on top of whatever
+// deadline req's own context already carries.
+func (t *HTTPTransport) RoundTrip(req *http.Request, backend *contracts.Backend) (*http.Response, error) {
+	outReq, err := http.NewRequest(req.Method, backend.URL+req.URL.Path, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	outReq.Header = req.Header
+
+	ctx := req.Context()
+	var cancel context.CancelFunc
+	if t.requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.requestTimeout)
+	}
+	resp, err := t.client.Do(outReq.WithContext(ctx))
+	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return nil, err
+	}
+	if cancel != nil {
+		resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	}
+	return resp, nil
+}
+
+// cancelOnCloseBody releases a RequestTimeout context once the response
+// body is closed (or read to EOF and closed, by net/http's usual contract)
+// rather than as soon as Do returns, so the deadline keeps bounding body
+// reads instead of cutting them off immediately after headers arrive.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
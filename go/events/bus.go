@@ -0,0 +1,161 @@
+// Package events provides a lightweight in-process pub/sub bus so the
+// BackendRegistry, HealthChecker, ProbePool, and Prequal can publish
+// topology/selection changes for subscribers (e.g. the /events SSE endpoint
+// or a remote sink) instead of callers having to reconstruct that state by
+// polling ListBackends.
+package events
+
+import (
+	"time"
+)
+
+// Kind identifies the shape of an Event's Data.
+type Kind string
+
+const (
+	BackendRegistered   Kind = "backend_registered"
+	BackendUnregistered Kind = "backend_unregistered"
+	HealthChanged       Kind = "health_changed"
+	ProbeCompleted      Kind = "probe_completed"
+	PolicySelected      Kind = "policy_selected"
+)
+
+// Event is a single typed occurrence published to a Bus. Backend is the
+// affected backend's URL, empty for events with no single backend (none
+// currently). Data carries kind-specific fields, e.g. {"healthy": true} for
+// HealthChanged or {"latency_ms": 12.3, "rif": 2} for ProbeCompleted.
+type Event struct {
+	Kind    Kind
+	Backend string
+	Data    map[string]interface{}
+	Time    time.Time
+}
+
+// OverflowPolicy controls what Publish does when a subscriber's buffered
+// channel is full.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the subscriber's oldest buffered event to make
+	// room for the new one, favoring liveness over completeness. This is
+	// the right default for a debugging stream like /events.
+	DropOldest OverflowPolicy = iota
+	// Block waits for the subscriber to drain, favoring completeness over
+	// liveness. Use for sinks (e.g. a NATS/Kafka forwarder) that must not
+	// silently lose events.
+	Block
+)
+
+// Filter decides whether a subscriber wants e. A nil Filter accepts everything.
+type Filter func(e Event) bool
+
+type subscriber struct {
+	ch       chan Event
+	filter   Filter
+	overflow OverflowPolicy
+}
+
+// Bus fans Publish calls out to every Subscribe'd channel that accepts them.
+// The zero value is not usable; construct with NewBus. A nil *Bus is safe to
+// call Publish on (no-op), so callers can thread an optional bus through
+// constructors without nil-checking at every call site.
+type Bus struct {
+	subscribe   chan *subscriber
+	unsubscribe chan *subscriber
+	publish     chan Event
+	done        chan struct{}
+}
+
+// NewBus starts a Bus's dispatch loop and returns it.
+func NewBus() *Bus {
+	b := &Bus{
+		subscribe:   make(chan *subscriber),
+		unsubscribe: make(chan *subscriber),
+		publish:     make(chan Event, 64),
+		done:        make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *Bus) run() {
+	subs := make(map[*subscriber]struct{})
+	for {
+		select {
+		case s := <-b.subscribe:
+			subs[s] = struct{}{}
+		case s := <-b.unsubscribe:
+			if _, ok := subs[s]; ok {
+				delete(subs, s)
+				close(s.ch)
+			}
+		case e := <-b.publish:
+			for s := range subs {
+				if s.filter != nil && !s.filter(e) {
+					continue
+				}
+				deliver(s, e)
+			}
+		case <-b.done:
+			for s := range subs {
+				close(s.ch)
+			}
+			return
+		}
+	}
+}
+
+func deliver(s *subscriber, e Event) {
+	if s.overflow == Block {
+		s.ch <- e
+		return
+	}
+	select {
+	case s.ch <- e:
+		return
+	default:
+	}
+	// DropOldest: make room by discarding the oldest buffered event, then
+	// retry once; if a concurrent receive already drained it, just send.
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- e:
+	default:
+	}
+}
+
+// Subscribe registers a new subscriber with the given buffer size (<= 0
+// defaults to 16), an optional filter, and an overflow policy. It returns a
+// receive-only channel of matching events and an unsubscribe func that
+// closes it; callers must keep draining the channel (or call unsubscribe)
+// or, under Block, Publish can stall.
+func (b *Bus) Subscribe(bufferSize int, filter Filter, overflow OverflowPolicy) (<-chan Event, func()) {
+	if bufferSize <= 0 {
+		bufferSize = 16
+	}
+	s := &subscriber{ch: make(chan Event, bufferSize), filter: filter, overflow: overflow}
+	b.subscribe <- s
+	return s.ch, func() { b.unsubscribe <- s }
+}
+
+// Publish emits e to every current subscriber whose filter accepts it. Safe
+// to call on a nil *Bus (no-op), so producers can hold an optional bus.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.publish <- e
+}
+
+// Close stops the dispatch loop and closes every current subscriber's
+// channel. Publish after Close blocks forever once the 64-event publish
+// buffer is full; callers should stop publishing before closing.
+func (b *Bus) Close() {
+	close(b.done)
+}
@@ -0,0 +1,30 @@
+package events
+
+import "log"
+
+// RemoteSink is an external destination for events, e.g. NATSSink or
+// KafkaSink, so an outside controller can react to topology changes without
+// polling ListBackends.
+type RemoteSink interface {
+	Publish(e Event) error
+}
+
+// Forward subscribes to bus with Block overflow (a sink must not silently
+// drop events the way the /events debug stream can) and writes every event
+// to sink until stop is called. It runs in its own goroutine.
+func Forward(bus *Bus, sink RemoteSink) (stop func()) {
+	ch, unsubscribe := bus.Subscribe(256, nil, Block)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range ch {
+			if err := sink.Publish(e); err != nil {
+				log.Printf("events: forwarding to remote sink: %v", err)
+			}
+		}
+	}()
+	return func() {
+		unsubscribe()
+		<-done
+	}
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaSink forwards every Bus event as a JSON message to a single Kafka
+// topic, keyed by Backend so a downstream consumer can use log compaction
+// to keep only the latest event per backend.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink returns a sink that writes to topic on the given brokers.
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:     kafka.TCP(brokers...),
+		Topic:    topic,
+		Balancer: &kafka.LeastBytes{},
+	}}
+}
+
+// Publish implements RemoteSink.
+func (s *KafkaSink) Publish(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("events: marshaling event for kafka: %w", err)
+	}
+	return s.writer.WriteMessages(context.Background(), kafka.Message{Key: []byte(e.Backend), Value: payload})
+}
+
+// Close flushes and closes the underlying Kafka writer.
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
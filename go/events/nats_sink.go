@@ -0,0 +1,44 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSink forwards every Bus event as a JSON message on a single NATS
+// subject, letting an external controller react to topology/selection
+// changes (e.g. to update its own routing table) instead of polling
+// ListBackends.
+type NATSSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSSink connects to a NATS server at url and returns a sink that
+// publishes to subject. Callers typically wire the result into Forward.
+func NewNATSSink(url, subject string) (*NATSSink, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("events: connecting to nats at %s: %w", url, err)
+	}
+	return &NATSSink{conn: conn, subject: subject}, nil
+}
+
+// Publish implements RemoteSink.
+func (s *NATSSink) Publish(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("events: marshaling event for nats: %w", err)
+	}
+	return s.conn.Publish(s.subject, payload)
+}
+
+// Close drains and closes the underlying NATS connection.
+func (s *NATSSink) Close() {
+	if err := s.conn.Drain(); err != nil {
+		log.Printf("events: nats drain: %v", err)
+	}
+}
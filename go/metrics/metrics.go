@@ -0,0 +1,91 @@
+// Package metrics collects Prometheus metrics for selection-policy
+// decisions so they're debuggable in production without log scraping,
+// distinct from core.MetricsManager's in-process retry/hedge/RIF-latency
+// bookkeeping.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	LBSelectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_lb_selections_total",
+		Help: "Total number of backend picks made by a selection policy.",
+	}, []string{"balancer", "backend", "outcome"})
+
+	LBPickLatencySeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "openprequal_lb_pick_latency_seconds",
+		Help:    "Time taken by a selection policy to pick a backend.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"balancer"})
+
+	BackendRIF = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "openprequal_backend_rif",
+		Help: "Last probed requests-in-flight for a backend.",
+	}, []string{"backend"})
+
+	BackendLatencyMs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "openprequal_backend_latency_ms",
+		Help: "Last probed latency in milliseconds for a backend.",
+	}, []string{"backend"})
+
+	PrequalHotColdTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_prequal_hot_cold_total",
+		Help: "Total number of times Prequal classified a backend as hot or cold at selection time.",
+	}, []string{"backend", "classification"})
+
+	ProbesScheduledTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "openprequal_probes_scheduled_total",
+		Help: "Total number of probe tasks Prequal has scheduled.",
+	})
+
+	PolicyFallbackTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_policy_fallback_total",
+		Help: "Total number of times an unknown selection policy name fell back to the default policy.",
+	}, []string{"requested"})
+)
+
+// Handler exposes the registered collectors for scraping.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveSelection records that balancer picked backend, and whether the
+// pick was usable ("ok") or the policy had nothing to return ("empty").
+func ObserveSelection(balancer, backend, outcome string) {
+	LBSelectionsTotal.WithLabelValues(balancer, backend, outcome).Inc()
+}
+
+// ObserveBackendSample records the latest probed RIF/latency for backend,
+// e.g. from ProbePool.AddProbe.
+func ObserveBackendSample(backend string, rif, latencyMs float64) {
+	BackendRIF.WithLabelValues(backend).Set(rif)
+	BackendLatencyMs.WithLabelValues(backend).Set(latencyMs)
+}
+
+// ObserveHotCold records that Prequal classified backend as classification
+// ("hot" or "cold") when picking it.
+func ObserveHotCold(backend, classification string) {
+	PrequalHotColdTotal.WithLabelValues(backend, classification).Inc()
+}
+
+// ObserveProbesScheduled adds n newly scheduled probe tasks to the running
+// total.
+func ObserveProbesScheduled(n int) {
+	if n <= 0 {
+		return
+	}
+	ProbesScheduledTotal.Add(float64(n))
+}
+
+// ObservePolicyFallback records that requested named a policy with no
+// registered Factory, so the caller fell back to the default policy
+// instead.
+func ObservePolicyFallback(requested string) {
+	PolicyFallbackTotal.WithLabelValues(requested).Inc()
+}
@@ -1,11 +1,16 @@
 package contracts
 
 type HeartbeatRequest struct {
-	URL string `json:"url"`
+	URL    string  `json:"url"`
+	Weight float64 `json:"weight,omitempty"` // relative share for weight-aware balancers; <= 0 is treated as 1
 }
 
 type ProbeResponse struct {
 	RequestsInFlight  int64   `json:"requests_in_flight"`
 	AverageLatencyMs  float64 `json:"average_latency_ms"`
 	RIFKeyedLatencyMs float64 `json:"rif_keyed_latency_ms"`
+	LoadAvg1          float64 `json:"load_avg_1"`
+	LoadAvg5          float64 `json:"load_avg_5"`
+	CPUPercent        float64 `json:"cpu_percent"`
+	MemUsedPercent    float64 `json:"mem_used_percent"`
 }
@@ -0,0 +1,191 @@
+// Package healthcheck runs an active, proxy-side health checker so load
+// balancers stop trusting whatever the registry last happened to contain
+// and instead see a backend marked unhealthy shortly after it stops serving
+// its health endpoint.
+package healthcheck
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// Config controls how Checker probes each backend's health endpoint.
+type Config struct {
+	Path               string
+	Interval           time.Duration
+	Timeout            time.Duration
+	HealthyThreshold   int
+	UnhealthyThreshold int
+	ExpectedStatus     int
+}
+
+// ConfigFromEnv builds a Config from BACKEND_HEALTH_PATH and HEALTHCHECK_*
+// environment variables, defaulting to "/health" on a 5s interval, 2s
+// timeout, 2 consecutive passes/failures to flip status, and an expected
+// 200 response.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Path:               "/health",
+		Interval:           5 * time.Second,
+		Timeout:            2 * time.Second,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+		ExpectedStatus:     http.StatusOK,
+	}
+	if v := os.Getenv("BACKEND_HEALTH_PATH"); v != "" {
+		cfg.Path = v
+	}
+	if v := os.Getenv("HEALTHCHECK_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Interval = d
+		}
+	}
+	if v := os.Getenv("HEALTHCHECK_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Timeout = d
+		}
+	}
+	if v := os.Getenv("HEALTHCHECK_HEALTHY_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.HealthyThreshold = n
+		}
+	}
+	if v := os.Getenv("HEALTHCHECK_UNHEALTHY_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.UnhealthyThreshold = n
+		}
+	}
+	if v := os.Getenv("HEALTHCHECK_EXPECTED_STATUS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ExpectedStatus = n
+		}
+	}
+	return cfg
+}
+
+// Status is the latest health-check result for one backend, as reported by
+// the proxy's GET /health/backends endpoint.
+type Status struct {
+	URL           string    `json:"url"`
+	Healthy       bool      `json:"healthy"`
+	LastLatencyMs float64   `json:"last_latency_ms"`
+	LastCheck     time.Time `json:"last_check"`
+	Consecutive   int       `json:"consecutive"` // consecutive passes (positive) or failures (negative)
+}
+
+// Checker periodically issues GET requests against each registered
+// backend's health path and flips registry.BackendRegistry.SetHealthy once a
+// backend crosses Config's consecutive success/failure threshold, so a
+// single flaky probe doesn't flap a backend in and out of rotation.
+type Checker struct {
+	Registry registry.BackendRegistry
+	Config   Config
+
+	client *http.Client
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	status map[string]*Status
+}
+
+// NewChecker creates a Checker against reg using cfg. A nil logger falls
+// back to slog.Default().
+func NewChecker(reg registry.BackendRegistry, cfg Config, logger *slog.Logger) *Checker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Checker{
+		Registry: reg,
+		Config:   cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		logger:   logger,
+		status:   make(map[string]*Status),
+	}
+}
+
+// Run checks every registered backend once per Config.Interval until ctx is
+// done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, b := range c.Registry.ListBackends() {
+				c.check(b.URL)
+			}
+		}
+	}
+}
+
+func (c *Checker) check(url string) {
+	start := time.Now()
+	ok := c.probe(url)
+	latencyMs := float64(time.Since(start)) / float64(time.Millisecond)
+
+	c.mu.Lock()
+	st, exists := c.status[url]
+	if !exists {
+		st = &Status{URL: url, Healthy: true}
+		c.status[url] = st
+	}
+	if ok {
+		if st.Consecutive < 0 {
+			st.Consecutive = 0
+		}
+		st.Consecutive++
+		if !st.Healthy && st.Consecutive >= c.Config.HealthyThreshold {
+			st.Healthy = true
+			c.Registry.SetHealthy(url, true)
+			c.logger.Info("backend health recovered", slog.String("url", url))
+		}
+	} else {
+		if st.Consecutive > 0 {
+			st.Consecutive = 0
+		}
+		st.Consecutive--
+		if st.Healthy && -st.Consecutive >= c.Config.UnhealthyThreshold {
+			st.Healthy = false
+			c.Registry.SetHealthy(url, false)
+			c.logger.Warn("backend failed health check threshold, marking unhealthy", slog.String("url", url))
+		}
+	}
+	st.LastLatencyMs = latencyMs
+	st.LastCheck = time.Now()
+	healthy := st.Healthy
+	c.mu.Unlock()
+
+	observability.ObserveHealthcheck(url, ok, healthy)
+}
+
+func (c *Checker) probe(url string) bool {
+	resp, err := c.client.Get(url + c.Config.Path)
+	if err != nil {
+		c.logger.Debug("health check request failed", slog.String("url", url), slog.Any("error", err))
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == c.Config.ExpectedStatus
+}
+
+// Status returns a snapshot of the latest check result for every backend
+// Checker has observed, suitable for serializing as JSON.
+func (c *Checker) Status() []Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make([]Status, 0, len(c.status))
+	for _, st := range c.status {
+		result = append(result, *st)
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package loadbalancer
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// ewmaHalfLife returns the EWMA half-life for the ewma/p2c_ewma balancers
+// from LB_EWMA_HALFLIFE_MS, defaulting to 10s. Lives here rather than in
+// cmd/proxy/main.go so the ewma/p2c_ewma factories below can use it.
+func ewmaHalfLife() time.Duration {
+	const defaultMs = 10000
+	ms := defaultMs
+	if v := os.Getenv("LB_EWMA_HALFLIFE_MS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			ms = parsed
+		}
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// init self-registers every built-in strategy under the LOAD_BALANCER_TYPE
+// value cmd/proxy/main.go already used for it, so createLoadBalancer's
+// hard-coded switch can be replaced with a Get lookup without changing any
+// deployment's env vars. consistent_hash (and its ip_hash/uri_hash/header_hash
+// aliases) and sticky are not registered here: consistent_hash needs a
+// per-request key function and sticky wraps another LoadBalancer, neither of
+// which fits Factory's signature, so main.go keeps constructing them
+// directly.
+func init() {
+	Register("random", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewRandomLoadBalancer(reg), nil
+	})
+	Register("roundrobin", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewRoundRobinLoadBalancer(reg), nil
+	})
+	Register("leastrif", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewLeastRequestsInFlightLoadBalancer(reg), nil
+	})
+	Register("leastlatency", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewLeastLatencyLoadBalancer(reg), nil
+	})
+	Register("power2_leastrif", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewPowerOfTwoLeastRIFLoadBalancer(reg), nil
+	})
+	Register("power2_leastlatency", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewPowerOfTwoLeastLatencyLoadBalancer(reg), nil
+	})
+	Register("prequal", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewPrequalLoadBalancer(reg), nil
+	})
+	Register("loadaware", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewLoadAwareLoadBalancer(reg, ParseLoadWeights(os.Getenv("LB_WEIGHTS"))), nil
+	})
+	Register("ewma", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewEWMALoadBalancer(reg, ewmaHalfLife()), nil
+	})
+	Register("p2c_ewma", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewP2CEWMALoadBalancer(reg, ewmaHalfLife()), nil
+	})
+	Register("wrr", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewWeightedRoundRobinLoadBalancer(reg), nil
+	})
+	Register("weighted_random", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewWeightedRandomLoadBalancer(reg), nil
+	})
+	Register("weighted_shuffle", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewWeightedShuffleLoadBalancer(reg), nil
+	})
+	Register("leastinflight", func(reg registry.BackendRegistry) (LoadBalancer, error) {
+		return NewLeastInFlightLoadBalancer(reg), nil
+	})
+}
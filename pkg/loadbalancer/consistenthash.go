@@ -0,0 +1,141 @@
+package loadbalancer
+
+import (
+	"hash/fnv"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// defaultVirtualNodesPerBackend is how many points each backend gets on the
+// hash ring when VirtualNodesPerBackend isn't set.
+const defaultVirtualNodesPerBackend = 160
+
+// RequestAwareLoadBalancer is a balancer whose pick depends on the request
+// and may write to the response, e.g. to set an affinity cookie.
+// StickyLoadBalancer and ConsistentHashLoadBalancer both implement it.
+type RequestAwareLoadBalancer interface {
+	PickBackend(r *http.Request, w http.ResponseWriter) string
+}
+
+// ConsistentHashLoadBalancer maps a request-derived key onto a hash ring of
+// virtual backend nodes so the same key consistently lands on the same
+// backend, and only ~1/N of keys remap when the backend set changes.
+type ConsistentHashLoadBalancer struct {
+	Registry registry.BackendRegistry
+	// KeyFunc derives the ring key from the request, e.g. client IP, a
+	// header value, or the request path.
+	KeyFunc                func(r *http.Request) string
+	VirtualNodesPerBackend int
+
+	mu      sync.Mutex
+	points  []uint32
+	owners  map[uint32]string
+	members string // fingerprint of the sorted backend URL set the ring was built from
+}
+
+// NewConsistentHashLoadBalancer builds a balancer that hashes requests via
+// keyFunc onto a ring with vnodes virtual points per backend (<= 0 uses
+// defaultVirtualNodesPerBackend).
+func NewConsistentHashLoadBalancer(reg registry.BackendRegistry, keyFunc func(r *http.Request) string, vnodes int) *ConsistentHashLoadBalancer {
+	if vnodes <= 0 {
+		vnodes = defaultVirtualNodesPerBackend
+	}
+	return &ConsistentHashLoadBalancer{
+		Registry:               reg,
+		KeyFunc:                keyFunc,
+		VirtualNodesPerBackend: vnodes,
+	}
+}
+
+// ConsistentHashKeyFunc builds a ring-key function from spec: "ip" (client
+// address, the default), "path" (request path), or "header:<Name>" (a
+// request header). Unrecognized specs fall back to "ip".
+func ConsistentHashKeyFunc(spec string) func(r *http.Request) string {
+	switch {
+	case spec == "path":
+		return func(r *http.Request) string { return r.URL.Path }
+	case strings.HasPrefix(spec, "header:"):
+		name := strings.TrimPrefix(spec, "header:")
+		return func(r *http.Request) string { return r.Header.Get(name) }
+	default:
+		return func(r *http.Request) string {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				return r.RemoteAddr
+			}
+			return host
+		}
+	}
+}
+
+func ringHash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// rebuild recomputes the ring from backends. Must be called with mu held.
+func (lb *ConsistentHashLoadBalancer) rebuild(urls []string) {
+	points := make([]uint32, 0, len(urls)*lb.VirtualNodesPerBackend)
+	owners := make(map[uint32]string, len(urls)*lb.VirtualNodesPerBackend)
+	for _, url := range urls {
+		for i := 0; i < lb.VirtualNodesPerBackend; i++ {
+			p := ringHash(url + "#" + strconv.Itoa(i))
+			if _, exists := owners[p]; exists {
+				continue // vanishingly rare collision; keep the first owner
+			}
+			points = append(points, p)
+			owners[p] = url
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+
+	lb.points = points
+	lb.owners = owners
+	lb.members = strings.Join(urls, ",")
+}
+
+// PickBackend hashes r via KeyFunc and walks the ring clockwise from that
+// point, skipping any owner no longer present in the registry, until it
+// finds a live backend or exhausts the ring. w is unused; consistent
+// hashing doesn't need a cookie to maintain affinity.
+func (lb *ConsistentHashLoadBalancer) PickBackend(r *http.Request, _ http.ResponseWriter) string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+	live := make(map[string]struct{}, len(backends))
+	urls := make([]string, len(backends))
+	for i, b := range backends {
+		live[b.URL] = struct{}{}
+		urls[i] = b.URL
+	}
+	sort.Strings(urls)
+	members := strings.Join(urls, ",")
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.members != members {
+		lb.rebuild(urls)
+	}
+	if len(lb.points) == 0 {
+		return ""
+	}
+
+	key := ringHash(lb.KeyFunc(r))
+	start := sort.Search(len(lb.points), func(i int) bool { return lb.points[i] >= key })
+	for i := 0; i < len(lb.points); i++ {
+		idx := (start + i) % len(lb.points)
+		owner := lb.owners[lb.points[idx]]
+		if _, ok := live[owner]; ok {
+			return owner
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package loadbalancer
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// defaultEWMAHalfLife is used when a load balancer is constructed with a
+// non-positive half-life.
+const defaultEWMAHalfLife = 10 * time.Second
+
+// EWMADecay blends sample into old using exponential decay over elapsed
+// time, halving old's influence every halfLife. A non-positive halfLife
+// disables smoothing and returns sample unchanged. For a backend with no
+// prior EWMA, callers pass an elapsed of halfLife*1000 (see
+// ewmaTracker.update), which makes the result effectively sample.
+func EWMADecay(old, sample float64, elapsed, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return sample
+	}
+	factor := math.Exp(-elapsed.Seconds() * math.Ln2 / halfLife.Seconds())
+	return factor*old + (1-factor)*sample
+}
+
+// ewmaEntry tracks the decayed latency/RIF estimate for one backend.
+type ewmaEntry struct {
+	latency    float64
+	rif        float64
+	lastUpdate time.Time
+	seen       bool
+}
+
+// ewmaTracker maintains per-backend EWMA entries shared by EWMALoadBalancer
+// and P2CEWMALoadBalancer.
+type ewmaTracker struct {
+	halfLife time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*ewmaEntry
+}
+
+func newEWMATracker(halfLife time.Duration) *ewmaTracker {
+	if halfLife <= 0 {
+		halfLife = defaultEWMAHalfLife
+	}
+	return &ewmaTracker{halfLife: halfLife, entries: make(map[string]*ewmaEntry)}
+}
+
+// update folds b's current samples into its EWMA entry and returns the
+// refreshed (latency, rif) estimate.
+func (t *ewmaTracker) update(b *registry.BackendInfo) (latency, rif float64) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[b.URL]
+	if !ok {
+		e = &ewmaEntry{}
+		t.entries[b.URL] = e
+	}
+	var elapsed time.Duration
+	if e.seen {
+		elapsed = now.Sub(e.lastUpdate)
+	} else {
+		// first sample: no history to decay against
+		elapsed = t.halfLife * 1000
+		e.seen = true
+	}
+	e.latency = EWMADecay(e.latency, b.AverageLatencyMs, elapsed, t.halfLife)
+	e.rif = EWMADecay(e.rif, float64(b.RequestsInFlight), elapsed, t.halfLife)
+	e.lastUpdate = now
+	return e.latency, e.rif
+}
+
+// EWMALoadBalancer picks the backend with the lowest exponentially
+// decayed average latency, reacting to recent samples faster than a plain
+// running average while still smoothing out noise.
+type EWMALoadBalancer struct {
+	Registry registry.BackendRegistry
+	tracker  *ewmaTracker
+}
+
+// NewEWMALoadBalancer creates an EWMALoadBalancer whose EWMA halves its
+// weight on samples older than halfLife.
+func NewEWMALoadBalancer(reg registry.BackendRegistry, halfLife time.Duration) *EWMALoadBalancer {
+	return &EWMALoadBalancer{Registry: reg, tracker: newEWMATracker(halfLife)}
+}
+
+func (lb *EWMALoadBalancer) PickBackend() string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+	bestIdx := -1
+	bestLatency := math.MaxFloat64
+	for i, b := range backends {
+		latency, _ := lb.tracker.update(b)
+		if latency < bestLatency {
+			bestLatency = latency
+			bestIdx = i
+		}
+	}
+	if bestIdx == -1 {
+		return backends[0].URL
+	}
+	return backends[bestIdx].URL
+}
+
+// P2CEWMALoadBalancer picks two random backends and chooses the one with
+// the lower ewmaLatency*(ewmaRIF+1) score, a Finagle-style aperture that
+// penalizes backends carrying both high latency and a deep queue.
+type P2CEWMALoadBalancer struct {
+	Registry registry.BackendRegistry
+	tracker  *ewmaTracker
+}
+
+// NewP2CEWMALoadBalancer creates a P2CEWMALoadBalancer whose EWMA halves its
+// weight on samples older than halfLife.
+func NewP2CEWMALoadBalancer(reg registry.BackendRegistry, halfLife time.Duration) *P2CEWMALoadBalancer {
+	return &P2CEWMALoadBalancer{Registry: reg, tracker: newEWMATracker(halfLife)}
+}
+
+func (lb *P2CEWMALoadBalancer) PickBackend() string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+	// refresh EWMA estimates for every backend before sampling from them
+	scores := make([]float64, len(backends))
+	for i, b := range backends {
+		latency, rif := lb.tracker.update(b)
+		scores[i] = latency * (rif + 1)
+	}
+	if len(backends) == 1 {
+		return backends[0].URL
+	}
+	idx1 := rand.Intn(len(backends))
+	idx2 := rand.Intn(len(backends))
+	for idx2 == idx1 {
+		idx2 = rand.Intn(len(backends))
+	}
+	if scores[idx1] <= scores[idx2] {
+		return backends[idx1].URL
+	}
+	return backends[idx2].URL
+}
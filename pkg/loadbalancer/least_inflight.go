@@ -0,0 +1,108 @@
+package loadbalancer
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// inflightEntry tracks a single backend's live request count independently
+// of registry.BackendInfo.RequestsInFlight, which only updates when the
+// periodic prober's UpdateMetrics call lands; Acquire/Release give
+// LeastInFlightLoadBalancer an up-to-the-request view instead.
+type inflightEntry struct {
+	count  atomic.Int64
+	weight float64
+}
+
+// LeastInFlightLoadBalancer picks the healthy backend with the fewest
+// in-flight requests as tracked by Acquire/Release, tie-breaking in favor of
+// the higher-weight backend. The caller (the proxy handler) must call
+// Acquire before dispatching to the returned backend and Release once that
+// request completes, via defer so a panic can't leak the count.
+type LeastInFlightLoadBalancer struct {
+	Registry registry.BackendRegistry
+
+	mu      sync.Mutex
+	entries map[string]*inflightEntry
+}
+
+func NewLeastInFlightLoadBalancer(reg registry.BackendRegistry) *LeastInFlightLoadBalancer {
+	return &LeastInFlightLoadBalancer{Registry: reg, entries: make(map[string]*inflightEntry)}
+}
+
+// entryFor returns (creating if necessary) the inflightEntry for url,
+// without touching its cached weight. Used by Acquire/Release, which only
+// ever mutate count.
+func (lb *LeastInFlightLoadBalancer) entryFor(url string) *inflightEntry {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	e, ok := lb.entries[url]
+	if !ok {
+		e = &inflightEntry{}
+		lb.entries[url] = e
+	}
+	return e
+}
+
+// weightedEntryFor returns (creating if necessary) the inflightEntry for
+// url, refreshing its cached weight from the registry. Used only by
+// PickBackend, so Acquire/Release can't clobber the real weight back to
+// defaultBackendWeight() on every request.
+func (lb *LeastInFlightLoadBalancer) weightedEntryFor(url string, weight float64) *inflightEntry {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	e, ok := lb.entries[url]
+	if !ok {
+		e = &inflightEntry{}
+		lb.entries[url] = e
+	}
+	if weight <= 0 {
+		weight = defaultBackendWeight()
+	}
+	e.weight = weight
+	return e
+}
+
+func (lb *LeastInFlightLoadBalancer) PickBackend() string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+
+	var bestURL string
+	bestCount := int64(math.MaxInt64)
+	bestWeight := -1.0
+	for _, b := range backends {
+		e := lb.weightedEntryFor(b.URL, b.Weight)
+		c := e.count.Load()
+		if c < bestCount || (c == bestCount && e.weight > bestWeight) {
+			bestURL = b.URL
+			bestCount = c
+			bestWeight = e.weight
+		}
+	}
+	return bestURL
+}
+
+// Acquire increments url's in-flight count; call before proxying a request
+// to it.
+func (lb *LeastInFlightLoadBalancer) Acquire(url string) {
+	lb.entryFor(url).count.Add(1)
+}
+
+// Release decrements url's in-flight count; call once that request
+// completes. A no-op only if url has no entry yet (neither Acquired nor
+// seen by PickBackend); otherwise it decrements even without a matching
+// Acquire.
+func (lb *LeastInFlightLoadBalancer) Release(url string) {
+	lb.mu.Lock()
+	e, ok := lb.entries[url]
+	lb.mu.Unlock()
+	if !ok {
+		return
+	}
+	e.count.Add(-1)
+}
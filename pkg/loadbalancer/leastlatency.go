@@ -17,7 +17,7 @@ func NewLeastLatencyLoadBalancer(reg registry.BackendRegistry) *LeastLatencyLoad
 }
 
 func (lb *LeastLatencyLoadBalancer) PickBackend() string {
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		return ""
 	}
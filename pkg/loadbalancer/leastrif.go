@@ -17,7 +17,7 @@ func NewLeastRequestsInFlightLoadBalancer(reg registry.BackendRegistry) *LeastRe
 }
 
 func (lb *LeastRequestsInFlightLoadBalancer) PickBackend() string {
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		return ""
 	}
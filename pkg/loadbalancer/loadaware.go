@@ -0,0 +1,181 @@
+package loadbalancer
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
+	"github.com/Pranshu258/OpenPrequal/pkg/probe"
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// loadAwareProbeInterval is how often host signals (CPU, load average,
+// memory) are refreshed per backend.
+const loadAwareProbeInterval = 1 * time.Second
+
+// defaultNumCPU is used to normalize load average when the backend's actual
+// core count isn't known to the proxy.
+const defaultNumCPU = 1.0
+
+// LoadWeights controls how much each signal contributes to a backend's
+// composite score in LoadAwareLoadBalancer. They need not sum to 1.
+type LoadWeights struct {
+	RIF  float64
+	Lat  float64
+	CPU  float64
+	Load float64
+}
+
+// defaultLoadWeights mirrors the example in LB_WEIGHTS: rif=0.4,lat=0.3,cpu=0.2,load=0.1.
+var defaultLoadWeights = LoadWeights{RIF: 0.4, Lat: 0.3, CPU: 0.2, Load: 0.1}
+
+// ParseLoadWeights parses a string like "rif=0.4,lat=0.3,cpu=0.2,load=0.1"
+// into a LoadWeights, falling back to defaultLoadWeights for any term that's
+// missing or malformed.
+func ParseLoadWeights(s string) LoadWeights {
+	w := defaultLoadWeights
+	if s == "" {
+		return w
+	}
+	for _, term := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(term), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "rif":
+			w.RIF = v
+		case "lat":
+			w.Lat = v
+		case "cpu":
+			w.CPU = v
+		case "load":
+			w.Load = v
+		}
+	}
+	return w
+}
+
+// hostSignals is the last-probed host saturation snapshot for a backend.
+type hostSignals struct {
+	loadAvg1       float64
+	cpuPercent     float64
+	memUsedPercent float64
+}
+
+// LoadAwareLoadBalancer picks the backend with the lowest composite score of
+// queue depth, latency, CPU, and load average, so operators can blend
+// Prequal-style queue signals with host saturation when replicas are
+// colocated with other workloads.
+type LoadAwareLoadBalancer struct {
+	Registry registry.BackendRegistry
+	Weights  LoadWeights
+
+	prober *probe.Prober
+	logger *slog.Logger
+	stop   chan struct{}
+
+	mu      sync.Mutex
+	signals map[string]hostSignals
+}
+
+// NewLoadAwareLoadBalancer creates a LoadAwareLoadBalancer that refreshes
+// host signals for reg's backends on loadAwareProbeInterval.
+func NewLoadAwareLoadBalancer(reg registry.BackendRegistry, weights LoadWeights) *LoadAwareLoadBalancer {
+	logger := observability.NewLogger("").With(slog.String("component", "loadaware_lb"))
+	lb := &LoadAwareLoadBalancer{
+		Registry: reg,
+		Weights:  weights,
+		prober:   probe.NewProber(logger),
+		logger:   logger,
+		stop:     make(chan struct{}),
+		signals:  make(map[string]hostSignals),
+	}
+	go lb.refreshLoop()
+	return lb
+}
+
+func (lb *LoadAwareLoadBalancer) refreshLoop() {
+	ticker := time.NewTicker(loadAwareProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lb.stop:
+			return
+		case <-ticker.C:
+			for _, b := range filterHealthy(lb.Registry.ListBackends()) {
+				result, err := lb.prober.ProbeBackend(b.URL)
+				if err != nil {
+					continue
+				}
+				lb.mu.Lock()
+				lb.signals[b.URL] = hostSignals{
+					loadAvg1:       result.LoadAvg1,
+					cpuPercent:     result.CPUPercent,
+					memUsedPercent: result.MemUsedPercent,
+				}
+				lb.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Stop halts the background host-signal refresh loop.
+func (lb *LoadAwareLoadBalancer) Stop() {
+	close(lb.stop)
+}
+
+func (lb *LoadAwareLoadBalancer) PickBackend() string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+
+	maxRIF := 0.0
+	maxLat := 0.0
+	for _, b := range backends {
+		if rif := float64(b.RequestsInFlight); rif > maxRIF {
+			maxRIF = rif
+		}
+		if b.AverageLatencyMs > maxLat {
+			maxLat = b.AverageLatencyMs
+		}
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	bestIdx := -1
+	bestScore := math.MaxFloat64
+	for i, b := range backends {
+		sig := lb.signals[b.URL]
+		score := lb.Weights.RIF*safeRatio(float64(b.RequestsInFlight), maxRIF) +
+			lb.Weights.Lat*safeRatio(b.AverageLatencyMs, maxLat) +
+			lb.Weights.CPU*(sig.cpuPercent/100.0) +
+			lb.Weights.Load*(sig.loadAvg1/defaultNumCPU)
+		if score < bestScore {
+			bestScore = score
+			bestIdx = i
+		}
+	}
+	if bestIdx == -1 {
+		return backends[0].URL
+	}
+	return backends[bestIdx].URL
+}
+
+// safeRatio returns 0 instead of NaN when max is 0.
+func safeRatio(v, max float64) float64 {
+	if max == 0 {
+		return 0
+	}
+	return v / max
+}
@@ -0,0 +1,23 @@
+package loadbalancer
+
+import "github.com/Pranshu258/OpenPrequal/pkg/registry"
+
+// LoadBalancer selects the URL of the backend that should serve the next
+// request.
+type LoadBalancer interface {
+	PickBackend() string
+}
+
+// filterHealthy returns the subset of backends with Healthy set, so
+// strategies that don't otherwise rank by load (RoundRobin, Random,
+// PowerOfTwoLeastLatency, ...) don't route traffic to a backend the active
+// health checker has flagged as down.
+func filterHealthy(backends []*registry.BackendInfo) []*registry.BackendInfo {
+	healthy := make([]*registry.BackendInfo, 0, len(backends))
+	for _, b := range backends {
+		if b.Healthy {
+			healthy = append(healthy, b)
+		}
+	}
+	return healthy
+}
@@ -17,7 +17,7 @@ func NewPowerOfTwoLeastLatencyLoadBalancer(reg registry.BackendRegistry) *PowerO
 }
 
 func (lb *PowerOfTwoLeastLatencyLoadBalancer) PickBackend() string {
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		return ""
 	}
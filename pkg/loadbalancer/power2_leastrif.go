@@ -17,7 +17,7 @@ func NewPowerOfTwoLeastRIFLoadBalancer(reg registry.BackendRegistry) *PowerOfTwo
 }
 
 func (lb *PowerOfTwoLeastRIFLoadBalancer) PickBackend() string {
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		return ""
 	}
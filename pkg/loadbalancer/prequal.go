@@ -1,18 +1,24 @@
 package loadbalancer
 
 import (
+	"container/heap"
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"math"
 	"math/rand"
+	"os"
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/Pranshu258/OpenPrequal/pkg/metrics"
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
 	"github.com/Pranshu258/OpenPrequal/pkg/probe"
 	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+	"gonum.org/v1/gonum/stat"
 )
 
 // Configuration constants for probing and request tracking
@@ -23,6 +29,57 @@ const (
 	probeWorkerCount       = 4                     // number of parallel probe workers
 	maxRequestBuffer       = 100000                // capacity for ring buffer of request timestamps
 	starvationThresholdSec = int64(1)              // consider a backend starved if not picked within this many seconds
+
+	defaultMaxProbePool       = 1000 // default bound on tracked backends in the probe pool
+	defaultQRIFThreshold      = 0.75 // default quantile of RIFs above which a replica is "hot"
+	defaultProbeRate          = 3    // default probes piggybacked per forwarded request
+	defaultToleranceFactor    = 0.05 // default (max-min)/max spread treated as "equal enough"
+	defaultCheckRequestNum    = 128  // default PickBackend calls between tolerance-score recomputes
+	defaultReferenceLatencyMs = 10.0 // default latency (ms) that normalizes the ModeP2C score formula
+
+	// Priority bases fed into ProbeTaskQueue.AddTask: forced probes outrank
+	// probabilistic and piggybacked ones once every backend has been probed,
+	// since a backend crossing forcedProbeIntervalSec unprobed is already
+	// the most urgent signal we have. Staleness is added in whole seconds, so
+	// a backend never probed yet (lastProbeTime 0, staleness the full Unix
+	// time) outranks any base until its first forced probe is scheduled.
+	forcedProbePriorityBase        = 1000.0
+	probabilisticProbePriorityBase = 0.0
+	piggybackProbePriorityBase     = 10.0
+)
+
+// envFloat reads a float64 tunable from the environment, falling back to def.
+func envFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// envInt reads an int tunable from the environment, falling back to def.
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+// SelectionMode picks which strategy PickBackend uses to score candidates.
+type SelectionMode string
+
+const (
+	// ModeFull scores every healthy backend on each pick (classifyByQRIF +
+	// selectColdBackend/selectHotBackend, or the tolerance-factor fast
+	// path). O(N) per pick; this is PrequalLoadBalancer's original behavior.
+	ModeFull SelectionMode = "full"
+	// ModeP2C samples two distinct healthy backends uniformly at random and
+	// picks the lower-scoring one (the "power of two choices" variant from
+	// the Prequal paper), so per-pick work is O(1) regardless of fleet size.
+	ModeP2C SelectionMode = "p2c"
 )
 
 // PrequalLoadBalancer selects the cold backend with lowest latency, or if all are hot, the one with lowest RIF
@@ -44,35 +101,67 @@ type PrequalLoadBalancer struct {
 	// caches and small helpers
 	latencyCache     map[string]float64
 	latencyCacheTime map[string]int64 // ms
-	rifMedianCache   map[string]float64
-	rifLastCount     map[string]int
-	rifLastValue     map[string]float64
 	healthyCache     []string
-	healthyCacheTime int64 // ms
+	healthyCacheTime int64             // ms
+	hotColdCache     map[string]string // url -> HotCold, refreshed alongside healthyCache
 	// instrumentation for debugging distribution
 	pickCounts map[string]int64
 	// starvation avoidance: last time a backend was picked (unix seconds)
 	lastPickTime map[string]int64
+
+	// tunables, overridable via env (see envFloat/envInt above)
+	MaxProbePool       int           // bound on the number of backends tracked by the probe pool
+	QRIFThreshold      float64       // quantile of pooled RIFs above which a replica is classified "hot"
+	ProbeRate          int           // probes piggybacked per forwarded request
+	ToleranceFactor    float64       // (max-min)/max score spread below which backends are treated as equal
+	CheckRequestNum    int           // how many PickBackend calls between tolerance-score recomputes
+	Mode               SelectionMode // ModeFull (default) or ModeP2C
+	ReferenceLatencyMs float64       // latency (ms) that normalizes ModeP2C's score formula
+
+	// Tolerance-factor fast path (look-aside balancer optimization, as in
+	// Milvus proxy): when the pool's backends score within ToleranceFactor
+	// of each other, skip full scoring and round-robin a cached snapshot
+	// instead. Accessed without lb.mu so the fast path stays fast.
+	pickCounter   uint64       // total PickBackend calls, drives the CheckRequestNum cadence
+	rrCounter     uint64       // round-robin cursor while the fast path is active
+	useRoundRobin int32        // atomic bool: 1 while the fast path is active
+	fastSnapshot  atomic.Value // []string of healthy backend URLs, valid while useRoundRobin == 1
 }
 
 func NewPrequalLoadBalancer(reg registry.BackendRegistry) *PrequalLoadBalancer {
+	maxProbePool := envInt("MAX_PROBE_POOL", defaultMaxProbePool)
+	qrifThreshold := envFloat("QRIF_THRESHOLD", defaultQRIFThreshold)
+	probeRate := envInt("PROBE_RATE", defaultProbeRate)
+	toleranceFactor := envFloat("TOLERANCE_FACTOR", defaultToleranceFactor)
+	checkRequestNum := envInt("CHECK_REQUEST_NUM", defaultCheckRequestNum)
+	referenceLatencyMs := envFloat("REFERENCE_LATENCY_MS", defaultReferenceLatencyMs)
+	mode := SelectionMode(os.Getenv("PREQUAL_MODE"))
+	if mode != ModeP2C {
+		mode = ModeFull
+	}
+
 	lb := &PrequalLoadBalancer{
-		Registry:         reg,
-		stopProbe:        make(chan struct{}),
-		probeHistory:     make(map[string]struct{}),
-		lastProbeTime:    make(map[string]int64),
-		requestBuffer:    make([]int64, maxRequestBuffer),
-		latencyCache:     make(map[string]float64),
-		latencyCacheTime: make(map[string]int64),
-		rifMedianCache:   make(map[string]float64),
-		rifLastCount:     make(map[string]int),
-		rifLastValue:     make(map[string]float64),
-		pickCounts:       make(map[string]int64),
-		lastPickTime:     make(map[string]int64),
+		Registry:           reg,
+		stopProbe:          make(chan struct{}),
+		probeHistory:       make(map[string]struct{}),
+		lastProbeTime:      make(map[string]int64),
+		requestBuffer:      make([]int64, maxRequestBuffer),
+		latencyCache:       make(map[string]float64),
+		latencyCacheTime:   make(map[string]int64),
+		pickCounts:         make(map[string]int64),
+		lastPickTime:       make(map[string]int64),
+		hotColdCache:       make(map[string]string),
+		MaxProbePool:       maxProbePool,
+		QRIFThreshold:      qrifThreshold,
+		ProbeRate:          probeRate,
+		ToleranceFactor:    toleranceFactor,
+		CheckRequestNum:    checkRequestNum,
+		Mode:               mode,
+		ReferenceLatencyMs: referenceLatencyMs,
 	}
 	// initialize probe subsystem
 	lb.probeTaskQueue = NewProbeTaskQueue(100)
-	lb.probePool = NewProbePool(1000, 100)
+	lb.probePool = NewProbePool(maxProbePool, 100)
 	lb.probeManager = NewProbeManager(reg, lb.probeTaskQueue, lb.probePool, 20)
 	lb.startProbeScheduler()
 	lb.probeManager.Start()
@@ -124,12 +213,35 @@ func (lb *PrequalLoadBalancer) getHealthyCached() []string {
 	// rebuild
 	backends := lb.Registry.ListBackends()
 	healthy := lb.filterHealthyURLs(backends)
+	for _, b := range backends {
+		lb.hotColdCache[b.URL] = b.HotCold
+	}
 	lb.healthyCache = healthy
 	lb.healthyCacheTime = nowMs
 	lb.mu.Unlock()
 	return healthy
 }
 
+// probePriority scores how urgently url needs a fresh probe: staleness
+// since its last probe, a bonus if it's cold/unknown rather than confirmed
+// hot, and a bonus if it hasn't been picked within starvationThresholdSec.
+// base lets callers weight one scheduling source above another; callers
+// must hold lb.mu.
+func (lb *PrequalLoadBalancer) probePriority(url string, now int64, base float64) float64 {
+	staleness := now - lb.lastProbeTime[url]
+	if staleness < 0 {
+		staleness = 0
+	}
+	priority := base + float64(staleness)
+	if lb.hotColdCache[url] != "hot" {
+		priority += 50
+	}
+	if t, ok := lb.lastPickTime[url]; !ok || now-t >= starvationThresholdSec {
+		priority += 20
+	}
+	return priority
+}
+
 // helper: get latency with tiny cache; consult probePool first, fallback to registry probe avg
 func (lb *PrequalLoadBalancer) getLatencyCached(url string) (float64, bool) {
 	nowMs := time.Now().UnixNano() / 1e6
@@ -153,7 +265,7 @@ func (lb *PrequalLoadBalancer) getLatencyCached(url string) (float64, bool) {
 		}
 	}
 	// fallback: check registry Probe
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	for _, b := range backends {
 		if b.URL == url {
 			v := b.Probe.AverageLatency()
@@ -167,55 +279,23 @@ func (lb *PrequalLoadBalancer) getLatencyCached(url string) (float64, bool) {
 	return 0, false
 }
 
-// helper: compute median RIF from probePool with caching keyed by (count,last)
+// getMedianRIF returns url's median RIF, maintained incrementally by
+// probePool's P² estimator (see ProbePool.Median) rather than recomputed by
+// sorting a window on every call. Falls back to the registry's own probe
+// history if the pool has never seen a sample for url.
 func (lb *PrequalLoadBalancer) getMedianRIF(url string) float64 {
-	// fetch rifs
-	var rifs []float64
 	if lb.probePool != nil {
-		rifs = lb.probePool.GetRIFs(url)
-	}
-	// fallback to registry probe recentRIFs if none
-	if len(rifs) == 0 {
-		backends := lb.Registry.ListBackends()
-		for _, b := range backends {
-			if b.URL == url {
-				return b.Probe.MedianRIF()
-			}
+		if med, ok := lb.probePool.Median(url); ok {
+			return med
 		}
-		return 0
 	}
-	cnt := len(rifs)
-	last := rifs[cnt-1]
-	// check cache
-	lb.mu.Lock()
-	if c, ok := lb.rifLastCount[url]; ok {
-		if c == cnt && lb.rifLastValue[url] == last {
-			v := lb.rifMedianCache[url]
-			lb.mu.Unlock()
-			return v
+	backends := filterHealthy(lb.Registry.ListBackends())
+	for _, b := range backends {
+		if b.URL == url {
+			return b.Probe.MedianRIF()
 		}
 	}
-	lb.mu.Unlock()
-	// compute median
-	tmp := make([]float64, len(rifs))
-	copy(tmp, rifs)
-	sort.Float64s(tmp)
-	med := 0.0
-	n := len(tmp)
-	if n == 0 {
-		med = 0
-	} else if n%2 == 1 {
-		med = tmp[n/2]
-	} else {
-		med = (tmp[n/2-1] + tmp[n/2]) / 2
-	}
-	// update cache
-	lb.mu.Lock()
-	lb.rifMedianCache[url] = med
-	lb.rifLastCount[url] = cnt
-	lb.rifLastValue[url] = last
-	lb.mu.Unlock()
-	return med
+	return 0
 }
 
 // recordRequest logs the current timestamp and removes entries outside the request window
@@ -244,14 +324,48 @@ func (lb *PrequalLoadBalancer) recordRequest() {
 	}
 }
 
+// classifyByQRIF buckets backends into cold/hot sets based on where each
+// backend's median RIF (getMedianRIF, or its RequestsInFlight when that
+// median is 0) falls relative to the pool-wide QRIFThreshold quantile of
+// those values across all candidates, per the Prequal paper's QRIF rule.
+func (lb *PrequalLoadBalancer) classifyByQRIF(backends []*registry.BackendInfo) map[string]bool {
+	rifs := make([]float64, 0, len(backends))
+	current := make(map[string]float64, len(backends))
+	for _, b := range backends {
+		rif := lb.getMedianRIF(b.URL)
+		if rif <= 0 {
+			rif = float64(b.RequestsInFlight)
+		}
+		current[b.URL] = rif
+		rifs = append(rifs, rif)
+	}
+	isHot := make(map[string]bool, len(backends))
+	if len(rifs) == 0 {
+		return isHot
+	}
+	sorted := make([]float64, len(rifs))
+	copy(sorted, rifs)
+	sort.Float64s(sorted)
+	threshold := stat.Quantile(lb.QRIFThreshold, stat.Empirical, sorted, nil)
+	hotCount := 0
+	for _, b := range backends {
+		isHot[b.URL] = current[b.URL] > threshold
+		if isHot[b.URL] {
+			hotCount++
+		}
+	}
+	observability.SetPoolDistribution(hotCount, len(backends)-hotCount)
+	return isHot
+}
+
 // selectColdBackend returns a cold backend URL if available
-func (lb *PrequalLoadBalancer) selectColdBackend(backends []*registry.BackendInfo) (string, bool) {
+func (lb *PrequalLoadBalancer) selectColdBackend(backends []*registry.BackendInfo, isHot map[string]bool) (string, bool) {
 	coldIndices := []int{}
 	coldLatency := math.MaxFloat64
 	// gather latencies first
 	latencies := make([]float64, len(backends))
 	for i, b := range backends {
-		if b.HotCold != "cold" {
+		if isHot[b.URL] {
 			latencies[i] = math.Inf(1)
 			continue
 		}
@@ -302,11 +416,15 @@ func (lb *PrequalLoadBalancer) selectColdBackend(backends []*registry.BackendInf
 }
 
 // selectHotBackend returns a hot backend URL if available
-func (lb *PrequalLoadBalancer) selectHotBackend(backends []*registry.BackendInfo) (string, bool) {
+func (lb *PrequalLoadBalancer) selectHotBackend(backends []*registry.BackendInfo, isHot map[string]bool) (string, bool) {
 	// compute current RIFs and pick with epsilon tie-breaking
 	minRIF := math.MaxFloat64
 	rifs := make([]float64, len(backends))
 	for i, b := range backends {
+		if !isHot[b.URL] {
+			rifs[i] = math.Inf(1)
+			continue
+		}
 		// prefer median RIF from probePool (cached), fallback to current RequestsInFlight
 		med := lb.getMedianRIF(b.URL)
 		if med > 0 {
@@ -355,15 +473,127 @@ func (lb *PrequalLoadBalancer) selectHotBackend(backends []*registry.BackendInfo
 	return pick.URL, true
 }
 
+// p2cScore scores b for ModeP2C: medianRIF * (1 + latencyMs/ReferenceLatencyMs),
+// so a backend with no queued requests (medianRIF 0) always scores lowest
+// regardless of latency, and among queued backends a higher cached latency
+// raises the score proportionally.
+func (lb *PrequalLoadBalancer) p2cScore(b *registry.BackendInfo) float64 {
+	medianRIF := lb.getMedianRIF(b.URL)
+	latency, ok := lb.getLatencyCached(b.URL)
+	if !ok {
+		latency = b.Probe.AverageLatency()
+	}
+	refLatency := lb.ReferenceLatencyMs
+	if refLatency <= 0 {
+		refLatency = defaultReferenceLatencyMs
+	}
+	return medianRIF * (1 + latency/refLatency)
+}
+
+// selectP2C implements the Prequal paper's "power of two choices" variant:
+// sample two distinct healthy backends uniformly at random and pick the
+// lower-scoring one, breaking near-ties by lastPickTime to avoid starving
+// whichever backend loses every coin flip. Per-pick work is O(1) regardless
+// of fleet size, unlike selectColdBackend/selectHotBackend's O(N) scan.
+func (lb *PrequalLoadBalancer) selectP2C(backends []*registry.BackendInfo) (string, bool) {
+	healthy := make([]*registry.BackendInfo, 0, len(backends))
+	for _, b := range backends {
+		if b.Healthy {
+			healthy = append(healthy, b)
+		}
+	}
+	if len(healthy) == 0 {
+		return "", false
+	}
+	if len(healthy) == 1 {
+		return healthy[0].URL, true
+	}
+
+	i := rand.Intn(len(healthy))
+	j := rand.Intn(len(healthy) - 1)
+	if j >= i {
+		j++
+	}
+	a, b := healthy[i], healthy[j]
+	scoreA, scoreB := lb.p2cScore(a), lb.p2cScore(b)
+
+	eps := 1e-6
+	winner := a
+	if scoreB < scoreA-eps {
+		winner = b
+	} else if math.Abs(scoreA-scoreB) <= eps {
+		lb.mu.Lock()
+		ta, oka := lb.lastPickTime[a.URL]
+		tb, okb := lb.lastPickTime[b.URL]
+		lb.mu.Unlock()
+		switch {
+		case !oka:
+			winner = a
+		case !okb:
+			winner = b
+		case tb < ta:
+			winner = b
+		}
+	}
+	log.Printf("[Prequal][p2c] Selected backend: %s (score=%.6f vs %.6f)", winner.URL, scoreA, scoreB)
+	return winner.URL, true
+}
+
+// maybeUpdateToleranceScore runs every CheckRequestNum PickBackend calls. It
+// scores each backend (medianRIF*latency when hot, latency when cold, per
+// the look-aside balancer optimization in Milvus proxy) and, if the pool's
+// scores are within ToleranceFactor of each other, flips on the fast path so
+// PickBackend round-robins a cached snapshot instead of re-scoring every
+// request until the pool diverges again.
+func (lb *PrequalLoadBalancer) maybeUpdateToleranceScore(backends []*registry.BackendInfo) {
+	if lb.CheckRequestNum <= 0 {
+		return
+	}
+	if atomic.AddUint64(&lb.pickCounter, 1)%uint64(lb.CheckRequestNum) != 0 {
+		return
+	}
+	isHot := lb.classifyByQRIF(backends)
+	min := math.MaxFloat64
+	max := 0.0
+	snapshot := make([]string, 0, len(backends))
+	for _, b := range backends {
+		lat, ok := lb.getLatencyCached(b.URL)
+		if !ok {
+			lat = b.Probe.AverageLatency()
+		}
+		score := lat
+		if isHot[b.URL] {
+			score = lb.getMedianRIF(b.URL) * lat
+		}
+		if score < min {
+			min = score
+		}
+		if score > max {
+			max = score
+		}
+		snapshot = append(snapshot, b.URL)
+	}
+	if len(snapshot) == 0 || max <= 0 || (max-min)/max > lb.ToleranceFactor {
+		atomic.StoreInt32(&lb.useRoundRobin, 0)
+		return
+	}
+	lb.fastSnapshot.Store(snapshot)
+	atomic.StoreInt32(&lb.useRoundRobin, 1)
+}
+
 func (lb *PrequalLoadBalancer) PickBackend() string {
 	// Track request rate
 	lb.recordRequest()
-	// Fetch available backends
-	backends := lb.Registry.ListBackends()
+	// Fetch available backends, excluding any the active health checker
+	// has flagged down.
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		log.Println("[Prequal] No backends available.")
 		return ""
 	}
+	// Piggyback ProbeRate probes onto this forwarded request instead of
+	// relying solely on the fixed-interval scheduler.
+	lb.piggybackProbes(backends)
 	// Early starvation avoidance: prefer any healthy backend that has been
 	// unpicked for at least starvationThresholdSec seconds.
 	nowSec := time.Now().Unix()
@@ -371,10 +601,6 @@ func (lb *PrequalLoadBalancer) PickBackend() string {
 	var earlyStarvedIdx = -1
 	var earlyOldest = int64(-1)
 	for i, b := range backends {
-		// only consider healthy backends
-		if b.HotCold == "" {
-			continue
-		}
 		if t, ok := lb.lastPickTime[b.URL]; !ok {
 			// never picked -> treat as starved and choose immediately
 			earlyStarvedIdx = i
@@ -397,21 +623,47 @@ func (lb *PrequalLoadBalancer) PickBackend() string {
 		return url
 	}
 	lb.mu.Unlock()
-	// Try selecting a cold backend first
-	if url, ok := lb.selectColdBackend(backends); ok {
-		lb.mu.Lock()
-		lb.pickCounts[url]++
-		lb.lastPickTime[url] = time.Now().Unix()
-		lb.mu.Unlock()
-		return url
-	}
-	// If no cold, pick hot based on lowest in-flight
-	if url, ok := lb.selectHotBackend(backends); ok {
-		lb.mu.Lock()
-		lb.pickCounts[url]++
-		lb.lastPickTime[url] = time.Now().Unix()
-		lb.mu.Unlock()
-		return url
+
+	if lb.Mode == ModeP2C {
+		if url, ok := lb.selectP2C(backends); ok {
+			lb.mu.Lock()
+			lb.pickCounts[url]++
+			lb.lastPickTime[url] = time.Now().Unix()
+			lb.mu.Unlock()
+			return url
+		}
+	} else {
+		lb.maybeUpdateToleranceScore(backends)
+		if atomic.LoadInt32(&lb.useRoundRobin) == 1 {
+			if snapshot, ok := lb.fastSnapshot.Load().([]string); ok && len(snapshot) > 0 {
+				idx := atomic.AddUint64(&lb.rrCounter, 1) % uint64(len(snapshot))
+				url := snapshot[idx]
+				lb.mu.Lock()
+				lb.pickCounts[url]++
+				lb.lastPickTime[url] = time.Now().Unix()
+				lb.mu.Unlock()
+				return url
+			}
+		}
+
+		// Classify the pool into hot/cold using the QRIFThreshold quantile
+		isHot := lb.classifyByQRIF(backends)
+		// Try selecting a cold backend first
+		if url, ok := lb.selectColdBackend(backends, isHot); ok {
+			lb.mu.Lock()
+			lb.pickCounts[url]++
+			lb.lastPickTime[url] = time.Now().Unix()
+			lb.mu.Unlock()
+			return url
+		}
+		// If no cold, pick hot based on lowest in-flight
+		if url, ok := lb.selectHotBackend(backends, isHot); ok {
+			lb.mu.Lock()
+			lb.pickCounts[url]++
+			lb.lastPickTime[url] = time.Now().Unix()
+			lb.mu.Unlock()
+			return url
+		}
 	}
 
 	// Fallback: random selection
@@ -447,7 +699,7 @@ func (lb *PrequalLoadBalancer) PickBackend() string {
 func (lb *PrequalLoadBalancer) filterHealthyURLs(backends []*registry.BackendInfo) []string {
 	healthy := make([]string, 0, len(backends))
 	for _, b := range backends {
-		if b.HotCold != "" {
+		if b.Healthy {
 			healthy = append(healthy, b.URL)
 		}
 	}
@@ -460,12 +712,13 @@ func (lb *PrequalLoadBalancer) scheduleForcedProbes(urls []string, now int64) {
 	defer lb.mu.Unlock()
 	for _, url := range urls {
 		if now-lb.lastProbeTime[url] >= forcedProbeIntervalSec {
+			priority := lb.probePriority(url, now, forcedProbePriorityBase)
 			lb.lastProbeTime[url] = now
-			// enqueue into deduplicated probe task queue
-			if err := lb.probeTaskQueue.AddTask(url); err != nil {
+			// enqueue into the priority probe task queue
+			if err := lb.probeTaskQueue.AddTask(url, priority); err != nil {
 				log.Printf("[Prequal] Probe queue full, dropping forced probe for backend: %s", url)
 			} else {
-				log.Printf("[Prequal] Forced probe scheduled for backend: %s", url)
+				log.Printf("[Prequal] Forced probe scheduled for backend: %s (priority=%.1f)", url, priority)
 			}
 		}
 	}
@@ -501,79 +754,325 @@ func (lb *PrequalLoadBalancer) scheduleProbabilisticProbe(urls []string, now int
 	if len(available) > 0 && rand.Float64() < R {
 		idx := rand.Intn(len(available))
 		url := available[idx]
+		priority := lb.probePriority(url, now, probabilisticProbePriorityBase)
 		lb.probeHistory[url] = struct{}{}
 		lb.lastProbeTime[url] = now
-		if err := lb.probeTaskQueue.AddTask(url); err != nil {
+		if err := lb.probeTaskQueue.AddTask(url, priority); err != nil {
 			log.Printf("[Prequal] Probe queue full, dropping probabilistic probe for backend: %s", url)
 		} else {
-			log.Printf("[Prequal] Probabilistic probe scheduled for backend: %s (R=%.3f, RPS=%.6f)", url, R, rps)
+			log.Printf("[Prequal] Probabilistic probe scheduled for backend: %s (R=%.3f, RPS=%.6f, priority=%.1f)", url, R, rps, priority)
 		}
 	}
 }
 
-// --- ProbeTaskQueue: deduplicated queue of probe tasks ---
+// piggybackProbes enqueues up to ProbeRate probes on the backends that were
+// just returned by ListBackends, asynchronously and without blocking the
+// caller. This is how probe issuance rides along on forwarded requests
+// instead of depending purely on the fixed-interval scheduler.
+func (lb *PrequalLoadBalancer) piggybackProbes(backends []*registry.BackendInfo) {
+	if lb.ProbeRate <= 0 || len(backends) == 0 {
+		return
+	}
+	urls := make([]string, len(backends))
+	for i, b := range backends {
+		urls[i] = b.URL
+	}
+	go func() {
+		now := time.Now().Unix()
+		for i := 0; i < lb.ProbeRate; i++ {
+			url := urls[rand.Intn(len(urls))]
+			lb.mu.Lock()
+			priority := lb.probePriority(url, now, piggybackProbePriorityBase)
+			lb.mu.Unlock()
+			if err := lb.probeTaskQueue.AddTask(url, priority); err != nil {
+				log.Printf("[Prequal] Probe queue full, dropping piggybacked probe for backend: %s", url)
+			}
+		}
+	}()
+}
+
+// --- ProbeTaskQueue: priority queue of probe tasks, deduplicated by URL ---
+
+// probeTask is one entry in the queue's heap: a backend URL, the urgency
+// score it was last enqueued with, and its current index in the heap (kept
+// up to date by probeTaskHeap.Swap so AddTask can re-key an existing entry
+// in place via heap.Fix instead of appending a duplicate).
+type probeTask struct {
+	url      string
+	priority float64
+	index    int
+}
+
+// probeTaskHeap is a max-heap by priority: container/heap always pops the
+// smallest element by Less, so Less is inverted to make Pop return the
+// highest-urgency task.
+type probeTaskHeap []*probeTask
+
+func (h probeTaskHeap) Len() int           { return len(h) }
+func (h probeTaskHeap) Less(i, j int) bool { return h[i].priority > h[j].priority }
+func (h probeTaskHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *probeTaskHeap) Push(x any) {
+	item := x.(*probeTask)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *probeTaskHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// ProbeTaskQueue is a bounded, deduplicated priority queue of probe tasks.
+// AddTask raises an already-queued URL's priority in place rather than
+// enqueueing it twice; GetTask always pops the highest-priority task, so
+// forced and stale probes don't sit behind whatever was enqueued first.
 type ProbeTaskQueue struct {
-	mu  sync.Mutex
-	set map[string]struct{}
-	ch  chan string
-	cap int
+	mu   sync.Mutex
+	heap probeTaskHeap
+	set  map[string]*probeTask
+	cap  int
+	wake chan struct{}
 }
 
 func NewProbeTaskQueue(capacity int) *ProbeTaskQueue {
 	return &ProbeTaskQueue{
-		set: make(map[string]struct{}),
-		ch:  make(chan string, capacity),
-		cap: capacity,
+		set:  make(map[string]*probeTask),
+		cap:  capacity,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+func (q *ProbeTaskQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
 	}
 }
 
-// AddTask adds a backend URL to the queue if not already present. Returns error if queue is full.
-func (q *ProbeTaskQueue) AddTask(url string) error {
+// AddTask enqueues url with the given priority. If url is already queued,
+// its priority is raised (never lowered) and it's sifted up in place.
+// Returns an error if the queue is at capacity and url isn't already queued.
+func (q *ProbeTaskQueue) AddTask(url string, priority float64) error {
 	q.mu.Lock()
-	if _, exists := q.set[url]; exists {
+	if item, exists := q.set[url]; exists {
+		if priority > item.priority {
+			item.priority = priority
+			heap.Fix(&q.heap, item.index)
+		}
 		q.mu.Unlock()
 		return nil
 	}
-	// optimistically mark as present
-	q.set[url] = struct{}{}
-	q.mu.Unlock()
-
-	select {
-	case q.ch <- url:
-		return nil
-	default:
-		// remove mark if we couldn't enqueue
-		q.mu.Lock()
-		delete(q.set, url)
+	if len(q.heap) >= q.cap {
 		q.mu.Unlock()
 		return fmt.Errorf("queue full")
 	}
+	item := &probeTask{url: url, priority: priority}
+	heap.Push(&q.heap, item)
+	q.set[url] = item
+	q.mu.Unlock()
+	q.notify()
+	return nil
 }
 
-// GetTask blocks until a task is available and returns the URL
-func (q *ProbeTaskQueue) GetTask() string {
-	url := <-q.ch
+// tryPop pops the highest-priority task without blocking.
+func (q *ProbeTaskQueue) tryPop() (string, bool) {
 	q.mu.Lock()
-	delete(q.set, url)
-	q.mu.Unlock()
-	return url
+	defer q.mu.Unlock()
+	if len(q.heap) == 0 {
+		return "", false
+	}
+	item := heap.Pop(&q.heap).(*probeTask)
+	delete(q.set, item.url)
+	return item.url, true
+}
+
+// GetTask blocks until a task is available and returns the highest-priority URL.
+func (q *ProbeTaskQueue) GetTask() string {
+	for {
+		if url, ok := q.tryPop(); ok {
+			return url
+		}
+		<-q.wake
+	}
+}
+
+// GetTaskContext is GetTask but returns (\"\", false) if ctx is done before a
+// task becomes available, so ProbeManager.run can stop promptly on Stop().
+func (q *ProbeTaskQueue) GetTaskContext(ctx context.Context) (string, bool) {
+	for {
+		if url, ok := q.tryPop(); ok {
+			return url, true
+		}
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-q.wake:
+		}
+	}
 }
 
 // Size returns number of unique tasks queued
 func (q *ProbeTaskQueue) Size() int {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return len(q.set)
+	return len(q.heap)
+}
+
+// p2Quantile is a constant-space streaming estimator for a single quantile
+// q, implementing the P² algorithm of Jain & Chlamtac: five markers bracket
+// the quantile's neighborhood and are repositioned by a parabolic (falling
+// back to linear) prediction on every sample, so the estimate converges in
+// O(1) time and space per sample without ever retaining the samples
+// themselves. The first five samples are buffered to seed the markers.
+type p2Quantile struct {
+	q       float64
+	initial []float64 // buffers the first 5 samples before the markers are seeded
+
+	heights [5]float64 // marker heights (the estimate lives at heights[2])
+	pos     [5]int      // actual marker positions
+	desired [5]float64  // desired marker positions
+	incr    [5]float64  // desired position increment per sample
+}
+
+func newP2Quantile(q float64) *p2Quantile {
+	return &p2Quantile{q: q}
+}
+
+// Add folds x into the estimator.
+func (e *p2Quantile) Add(x float64) {
+	if len(e.initial) < 5 {
+		e.initial = append(e.initial, x)
+		if len(e.initial) == 5 {
+			sort.Float64s(e.initial)
+			for i := 0; i < 5; i++ {
+				e.heights[i] = e.initial[i]
+				e.pos[i] = i + 1
+			}
+			e.desired = [5]float64{1, 1 + 2*e.q, 1 + 4*e.q, 3 + 2*e.q, 5}
+			e.incr = [5]float64{0, e.q / 2, e.q, (1 + e.q) / 2, 1}
+		}
+		return
+	}
+
+	k := 3
+	switch {
+	case x < e.heights[0]:
+		e.heights[0] = x
+		k = 0
+	case x >= e.heights[4]:
+		e.heights[4] = x
+		k = 3
+	default:
+		for i := 0; i < 4; i++ {
+			if e.heights[i] <= x && x < e.heights[i+1] {
+				k = i
+				break
+			}
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		e.pos[i]++
+	}
+	for i := 0; i < 5; i++ {
+		e.desired[i] += e.incr[i]
+	}
+
+	for i := 1; i <= 3; i++ {
+		d := e.desired[i] - float64(e.pos[i])
+		if (d >= 1 && e.pos[i+1]-e.pos[i] > 1) || (d <= -1 && e.pos[i-1]-e.pos[i] < -1) {
+			sign := 1
+			if d < 0 {
+				sign = -1
+			}
+			newHeight := e.parabolic(i, sign)
+			if e.heights[i-1] < newHeight && newHeight < e.heights[i+1] {
+				e.heights[i] = newHeight
+			} else {
+				e.heights[i] = e.linear(i, sign)
+			}
+			e.pos[i] += sign
+		}
+	}
+}
+
+func (e *p2Quantile) parabolic(i, d int) float64 {
+	fd := float64(d)
+	return e.heights[i] + fd/float64(e.pos[i+1]-e.pos[i-1])*
+		(float64(e.pos[i]-e.pos[i-1]+d)*(e.heights[i+1]-e.heights[i])/float64(e.pos[i+1]-e.pos[i])+
+			float64(e.pos[i+1]-e.pos[i]-d)*(e.heights[i]-e.heights[i-1])/float64(e.pos[i]-e.pos[i-1]))
+}
+
+func (e *p2Quantile) linear(i, d int) float64 {
+	j := i + d
+	return e.heights[i] + float64(d)*(e.heights[j]-e.heights[i])/float64(e.pos[j]-e.pos[i])
+}
+
+// Value returns the current quantile estimate. Before the 5th sample it
+// falls back to an exact quantile of whatever's been buffered so far.
+func (e *p2Quantile) Value() (float64, bool) {
+	n := len(e.initial)
+	if n == 0 {
+		return 0, false
+	}
+	if n < 5 {
+		tmp := make([]float64, n)
+		copy(tmp, e.initial)
+		sort.Float64s(tmp)
+		idx := int(e.q * float64(n-1))
+		return tmp[idx], true
+	}
+	return e.heights[2], true
 }
 
-// --- ProbePool: stores recent latencies and RIF history per backend ---
+// Snapshot returns a copy of the estimator's marker heights — a coarse,
+// 5-point approximation of the observed distribution, not the original
+// samples (which P² never retains).
+func (e *p2Quantile) Snapshot() []float64 {
+	if len(e.initial) < 5 {
+		out := make([]float64, len(e.initial))
+		copy(out, e.initial)
+		return out
+	}
+	out := make([]float64, 5)
+	copy(out, e.heights[:])
+	return out
+}
+
+// probeEWMAHalfLife is the half-life used to decay probeEntry's latency
+// mean, matching ewmaTracker's convention of reacting to recent samples
+// without the allocation churn of a sliding window.
+const probeEWMAHalfLife = 10 * time.Second
+
+// probeQuantiles are the RIF quantiles every probeEntry tracks eagerly;
+// getMedianRIF needs 0.5 on every pick, and 0.95 is kept alongside it as a
+// cheap tail-latency-style signal for future callers. Quantile seeds any
+// other q lazily on first use.
+var probeQuantiles = []float64{0.5, 0.95}
+
+// --- ProbePool: tracks per-backend latency/RIF estimates in constant space ---
+//
+// probeEntry used to keep sliding windows of up to 100 raw latency and RIF
+// samples, recomputing a mean (and, in getMedianRIF, a sort) on every read.
+// It now keeps only an EWMA of latency plus a handful of P² (Jain &
+// Chlamtac) streaming quantile estimators of RIF, so AddProbe is O(1) and
+// allocation-free regardless of probe rate.
 type probeEntry struct {
-	latencies []float64
-	rifs      []float64
-	maxHist   int
-	mu        sync.Mutex
-	last      time.Time
-	curLat    float64
+	mu   sync.Mutex
+	last time.Time
+	seen bool
+
+	latencyEWMA float64
+	rifQuants   map[float64]*p2Quantile
 }
 
 type ProbePool struct {
@@ -598,7 +1097,10 @@ func (p *ProbePool) ensureEntry(url string) *probeEntry {
 				break
 			}
 		}
-		e = &probeEntry{latencies: make([]float64, 0, 100), rifs: make([]float64, 0, 100), maxHist: 100}
+		e = &probeEntry{rifQuants: make(map[float64]*p2Quantile, len(probeQuantiles))}
+		for _, q := range probeQuantiles {
+			e.rifQuants[q] = newP2Quantile(q)
+		}
 		p.entries[url] = e
 	}
 	return e
@@ -608,25 +1110,24 @@ func (p *ProbePool) AddProbe(url string, latency float64, rif float64) {
 	e := p.ensureEntry(url)
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	if len(e.latencies) >= e.maxHist {
-		e.latencies = e.latencies[1:]
-	}
-	e.latencies = append(e.latencies, latency)
-	if len(e.rifs) >= e.maxHist {
-		e.rifs = e.rifs[1:]
-	}
-	e.rifs = append(e.rifs, rif)
-	e.last = time.Now()
-	// update current latency
-	sum := 0.0
-	for _, v := range e.latencies {
-		sum += v
+
+	now := time.Now()
+	var elapsed time.Duration
+	if e.seen {
+		elapsed = now.Sub(e.last)
+	} else {
+		elapsed = probeEWMAHalfLife * 1000 // first sample: no history to decay against
+		e.seen = true
 	}
-	if len(e.latencies) > 0 {
-		e.curLat = sum / float64(len(e.latencies))
+	e.latencyEWMA = EWMADecay(e.latencyEWMA, latency, elapsed, probeEWMAHalfLife)
+	e.last = now
+
+	for _, est := range e.rifQuants {
+		est.Add(rif)
 	}
 }
 
+// GetCurrentLatency returns url's exponentially decayed average latency.
 func (p *ProbePool) GetCurrentLatency(url string) (float64, bool) {
 	p.mu.Lock()
 	e, ok := p.entries[url]
@@ -636,9 +1137,13 @@ func (p *ProbePool) GetCurrentLatency(url string) (float64, bool) {
 	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	return e.curLat, true
+	return e.latencyEWMA, true
 }
 
+// GetRIFs returns an approximate snapshot of url's RIF distribution, taken
+// from the median estimator's internal markers. It no longer reflects the
+// full sample history (which is no longer retained) — callers that want a
+// quantile estimate should use Median or Quantile instead.
 func (p *ProbePool) GetRIFs(url string) []float64 {
 	p.mu.Lock()
 	e, ok := p.entries[url]
@@ -648,9 +1153,38 @@ func (p *ProbePool) GetRIFs(url string) []float64 {
 	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	out := make([]float64, len(e.rifs))
-	copy(out, e.rifs)
-	return out
+	est, ok := e.rifQuants[0.5]
+	if !ok {
+		return nil
+	}
+	return est.Snapshot()
+}
+
+// Median returns url's streaming median RIF estimate.
+func (p *ProbePool) Median(url string) (float64, bool) {
+	return p.Quantile(url, 0.5)
+}
+
+// Quantile returns url's streaming estimate of the q-th quantile of its RIF
+// samples, backed by a P² estimator that updates in O(1) per sample instead
+// of sorting a window. The first call for a q outside probeQuantiles seeds a
+// fresh estimator, so it only reflects samples observed from that point
+// forward — there is no retained history to backfill it with.
+func (p *ProbePool) Quantile(url string, q float64) (float64, bool) {
+	p.mu.Lock()
+	e, ok := p.entries[url]
+	p.mu.Unlock()
+	if !ok {
+		return 0, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	est, ok := e.rifQuants[q]
+	if !ok {
+		est = newP2Quantile(q)
+		e.rifQuants[q] = est
+	}
+	return est.Value()
 }
 
 // --- ProbeManager: consumes ProbeTaskQueue and runs probes with bounded concurrency ---
@@ -658,6 +1192,7 @@ type ProbeManager struct {
 	reg    registry.BackendRegistry
 	queue  *ProbeTaskQueue
 	pool   *ProbePool
+	prober probe.BackendProber
 	sem    chan struct{}
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -666,7 +1201,9 @@ type ProbeManager struct {
 
 func NewProbeManager(reg registry.BackendRegistry, q *ProbeTaskQueue, p *ProbePool, maxConcurrent int) *ProbeManager {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &ProbeManager{reg: reg, queue: q, pool: p, sem: make(chan struct{}, maxConcurrent), ctx: ctx, cancel: cancel}
+	logger := observability.NewLogger("").With(slog.String("component", "probe_manager"))
+	transport := os.Getenv("PROBE_TRANSPORT")
+	return &ProbeManager{reg: reg, queue: q, pool: p, prober: probe.NewBackendProber(transport, logger), sem: make(chan struct{}, maxConcurrent), ctx: ctx, cancel: cancel}
 }
 
 func (m *ProbeManager) Start() {
@@ -682,50 +1219,27 @@ func (m *ProbeManager) Stop() {
 func (m *ProbeManager) run() {
 	defer m.wg.Done()
 	for {
-		select {
-		case <-m.ctx.Done():
+		url, ok := m.queue.GetTaskContext(m.ctx)
+		if !ok {
 			return
-		default:
-			// blockingly get a task; but use select to allow cancel
-			var url string
-			select {
-			case <-m.ctx.Done():
+		}
+
+		// acquire semaphore
+		m.sem <- struct{}{}
+		m.wg.Add(1)
+		go func(u string) {
+			defer func() { <-m.sem; m.wg.Done() }()
+			result, err := m.prober.ProbeBackend(u)
+			if err != nil {
+				log.Printf("[ProbeManager] Probe failed for %s: %v", u, err)
 				return
-			case url = <-m.queue.ch:
-				// remove mark
-				m.queue.mu.Lock()
-				delete(m.queue.set, url)
-				m.queue.mu.Unlock()
 			}
-
-			// acquire semaphore
-			m.sem <- struct{}{}
-			m.wg.Add(1)
-			go func(u string) {
-				defer func() { <-m.sem; m.wg.Done() }()
-				result, err := probe.ProbeBackend(u)
-				if err != nil {
-					log.Printf("[ProbeManager] Probe failed for %s: %v", u, err)
-					return
-				}
-				// update probe pool
-				m.pool.AddProbe(u, result.RIFKeyedLatencyMs, float64(result.RequestsInFlight))
-				// update registry if in-memory using concurrency-safe helper
-				if memReg, ok := m.reg.(*registry.InMemoryBackendRegistry); ok {
-					memReg.UpdateBackend(u, func(b *registry.BackendInfo) {
-						b.RequestsInFlight = result.RequestsInFlight
-						b.AverageLatencyMs = result.AverageLatencyMs
-						b.RIFKeyedLatencyMs = result.RIFKeyedLatencyMs
-						// record recent request-in-flight and latency history
-						rif := float64(result.RequestsInFlight)
-						b.Probe.AddRIF(rif)
-						b.Probe.AddLatency(result.RIFKeyedLatencyMs)
-						b.HotCold = b.Probe.Status(rif)
-						metrics.LogProbeUpdate(u, b.RequestsInFlight, b.AverageLatencyMs, b.RIFKeyedLatencyMs, b.HotCold)
-					})
-				}
-			}(url)
-		}
+			// update probe pool
+			m.pool.AddProbe(u, result.RIFKeyedLatencyMs, float64(result.RequestsInFlight))
+			// update registry (works across any BackendRegistry implementation)
+			m.reg.UpdateMetrics(u, result.RequestsInFlight, result.AverageLatencyMs, result.RIFKeyedLatencyMs)
+			observability.ObserveBackendMetrics(u, float64(result.RequestsInFlight), result.AverageLatencyMs)
+		}(url)
 	}
 }
 
@@ -0,0 +1,58 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// newBenchRegistry builds a registry of n backends with a synthetic,
+// skewed RIF/latency distribution: a handful of backends are heavily
+// loaded ("hot"), the rest lightly loaded ("cold"), which is the herd
+// shape ModeP2C is meant to handle without an O(N) scan.
+func newBenchRegistry(n int) registry.BackendRegistry {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("http://backend-%d", i)
+	}
+	reg := registry.NewInMemoryBackendRegistry(urls)
+	r := rand.New(rand.NewSource(1))
+	for i, url := range urls {
+		rif := r.Float64() * 5
+		latency := 5 + r.Float64()*10
+		if i%10 == 0 {
+			// a minority of hot, heavily-loaded backends
+			rif = 50 + r.Float64()*50
+			latency = 80 + r.Float64()*40
+		}
+		reg.UpdateMetrics(url, int64(rif), latency, latency)
+	}
+	return reg
+}
+
+func benchmarkPickBackend(b *testing.B, mode SelectionMode, fleetSize int) {
+	lb := NewPrequalLoadBalancer(newBenchRegistry(fleetSize))
+	lb.Mode = mode
+	defer lb.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lb.PickBackend()
+	}
+}
+
+// BenchmarkPickBackend compares ModeFull against ModeP2C across a range of
+// fleet sizes, so picking a mode can be based on where the O(N) vs O(1)
+// per-pick cost actually starts to matter.
+func BenchmarkPickBackend(b *testing.B) {
+	for _, fleetSize := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("ModeFull/n=%d", fleetSize), func(b *testing.B) {
+			benchmarkPickBackend(b, ModeFull, fleetSize)
+		})
+		b.Run(fmt.Sprintf("ModeP2C/n=%d", fleetSize), func(b *testing.B) {
+			benchmarkPickBackend(b, ModeP2C, fleetSize)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package loadbalancer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// newPrequalTestRegistry registers urls and, for each, records a single
+// UpdateMetrics sample so both RequestsInFlight and the backend's Probe
+// history (median RIF, average latency) are seeded consistently, matching
+// how the registry is populated outside of tests.
+func newPrequalTestRegistry(urls []string, rif []int64, latencyMs []float64) registry.BackendRegistry {
+	reg := registry.NewInMemoryBackendRegistry(urls)
+	for i, url := range urls {
+		reg.UpdateMetrics(url, rif[i], latencyMs[i], latencyMs[i])
+	}
+	return reg
+}
+
+func backendInfos(reg registry.BackendRegistry) []*registry.BackendInfo {
+	return reg.ListBackends()
+}
+
+func TestClassifyByQRIF_SplitsHotAndColdAroundThreshold(t *testing.T) {
+	urls := []string{"b1", "b2", "b3", "b4"}
+	reg := newPrequalTestRegistry(urls, []int64{1, 1, 1, 100}, []float64{5, 5, 5, 5})
+
+	lb := NewPrequalLoadBalancer(reg)
+	defer lb.Stop()
+
+	isHot := lb.classifyByQRIF(backendInfos(reg))
+	for _, url := range []string{"b1", "b2", "b3"} {
+		if isHot[url] {
+			t.Errorf("expected %s to be classified cold, got hot", url)
+		}
+	}
+	if !isHot["b4"] {
+		t.Errorf("expected b4 (RIF 100) to be classified hot")
+	}
+}
+
+func TestSelectColdBackend_PrefersLowestLatency(t *testing.T) {
+	urls := []string{"c1", "c2", "c3"}
+	reg := newPrequalTestRegistry(urls, []int64{1, 1, 1}, []float64{25, 5, 15})
+
+	lb := NewPrequalLoadBalancer(reg)
+	defer lb.Stop()
+
+	isHot := map[string]bool{"c1": false, "c2": false, "c3": false}
+	url, ok := lb.selectColdBackend(backendInfos(reg), isHot)
+	if !ok {
+		t.Fatalf("expected a cold backend to be selected")
+	}
+	if url != "c2" {
+		t.Fatalf("expected c2 (lowest latency), got %s", url)
+	}
+}
+
+func TestSelectHotBackend_PrefersLowestRIF(t *testing.T) {
+	urls := []string{"h1", "h2", "h3"}
+	reg := newPrequalTestRegistry(urls, []int64{10, 1, 5}, []float64{5, 5, 5})
+
+	lb := NewPrequalLoadBalancer(reg)
+	defer lb.Stop()
+
+	isHot := map[string]bool{"h1": true, "h2": true, "h3": true}
+	url, ok := lb.selectHotBackend(backendInfos(reg), isHot)
+	if !ok {
+		t.Fatalf("expected a hot backend to be selected")
+	}
+	if url != "h2" {
+		t.Fatalf("expected h2 (lowest RIF), got %s", url)
+	}
+}
+
+func TestSelectP2C_PicksLowerScoringBackend(t *testing.T) {
+	urls := []string{"p1", "p2"}
+	// p1: RIF 0 -> p2cScore 0 regardless of latency.
+	// p2: RIF 5, latency 10ms -> a strictly positive score.
+	reg := newPrequalTestRegistry(urls, []int64{0, 5}, []float64{50, 10})
+
+	lb := NewPrequalLoadBalancer(reg)
+	defer lb.Stop()
+
+	url, ok := lb.selectP2C(backendInfos(reg))
+	if !ok {
+		t.Fatalf("expected selectP2C to pick a backend")
+	}
+	if url != "p1" {
+		t.Fatalf("expected p1 (score 0), got %s", url)
+	}
+}
+
+func TestP2Quantile_ConvergesToApproximateMedian(t *testing.T) {
+	q := newP2Quantile(0.5)
+	for i := 1; i <= 101; i++ {
+		q.Add(float64(i))
+	}
+	got, ok := q.Value()
+	if !ok {
+		t.Fatalf("expected a value after seeding 101 samples")
+	}
+	// The P² estimator is a coarse approximation, not an exact quantile;
+	// assert it lands near the true median (51) rather than exactly on it.
+	if math.Abs(got-51) > 10 {
+		t.Errorf("Value() = %v, want within 10 of true median 51", got)
+	}
+}
+
+func TestP2Quantile_ValueBeforeFifthSampleIsExact(t *testing.T) {
+	q := newP2Quantile(0.5)
+	q.Add(3)
+	q.Add(1)
+	q.Add(2)
+	got, ok := q.Value()
+	if !ok {
+		t.Fatalf("expected a value after 3 samples")
+	}
+	if got != 2 {
+		t.Errorf("Value() with 3 samples = %v, want exact median 2", got)
+	}
+}
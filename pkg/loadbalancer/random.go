@@ -17,9 +17,9 @@ func NewRandomLoadBalancer(reg registry.BackendRegistry) *RandomLoadBalancer {
 }
 
 func (lb *RandomLoadBalancer) PickBackend() string {
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		return ""
 	}
-	return backends[rand.Intn(len(backends))]
+	return backends[rand.Intn(len(backends))].URL
 }
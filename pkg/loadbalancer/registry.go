@@ -0,0 +1,45 @@
+package loadbalancer
+
+import (
+	"sync"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// Factory builds a LoadBalancer for a registered strategy name. Strategies
+// that need more than a BackendRegistry (ConsistentHashLoadBalancer's
+// per-request key function, StickyLoadBalancer's wrapped inner balancer) are
+// wired by hand in cmd/proxy/main.go instead of going through this registry.
+type Factory func(reg registry.BackendRegistry) (LoadBalancer, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a LoadBalancer factory available under name. Built-in
+// strategies self-register from init() in builtins.go; third parties can
+// call Register from their own main.go before createLoadBalancer runs to add
+// a strategy reachable via LOAD_BALANCER_TYPE without forking this package.
+// Register panics on a duplicate name, matching the database/sql driver
+// registration pattern it follows.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("loadbalancer: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("loadbalancer: Register called twice for strategy " + name)
+	}
+	factories[name] = factory
+}
+
+// Get looks up the factory registered under name. The bool return reports
+// whether name was found.
+func Get(name string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	factory, ok := factories[name]
+	return factory, ok
+}
@@ -0,0 +1,57 @@
+package loadbalancer
+
+import (
+	"hash/fnv"
+	"net/http"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// RendezvousHashLoadBalancer picks a backend for a request-derived key using
+// highest random weight (HRW/rendezvous) hashing: for each live backend it
+// computes hash(key + backend.URL) and returns the backend with the highest
+// value. Unlike a virtual-node ring (ConsistentHashLoadBalancer), HRW needs
+// no precomputed ring, and removing a backend only remaps the keys that used
+// to hash highest to it; every other key's pick is unaffected.
+type RendezvousHashLoadBalancer struct {
+	Registry registry.BackendRegistry
+	// KeyFunc derives the rendezvous key from the request, e.g. client IP, a
+	// header value, or the request path. Shares ConsistentHashKeyFunc specs.
+	KeyFunc func(r *http.Request) string
+}
+
+// NewRendezvousHashLoadBalancer builds a balancer that hashes requests via
+// keyFunc against reg's live backends.
+func NewRendezvousHashLoadBalancer(reg registry.BackendRegistry, keyFunc func(r *http.Request) string) *RendezvousHashLoadBalancer {
+	return &RendezvousHashLoadBalancer{Registry: reg, KeyFunc: keyFunc}
+}
+
+func rendezvousHash(key, url string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(url))
+	return h.Sum32()
+}
+
+// PickBackend returns the healthy backend whose hash(key, backend.URL) is
+// highest for r's derived key. w is unused: rendezvous hashing needs no
+// cookie to stay sticky. Returns "" if no backend is healthy.
+func (lb *RendezvousHashLoadBalancer) PickBackend(r *http.Request, _ http.ResponseWriter) string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+
+	key := lb.KeyFunc(r)
+	var best string
+	var bestScore uint32
+	for i, b := range backends {
+		score := rendezvousHash(key, b.URL)
+		if i == 0 || score > bestScore {
+			best = b.URL
+			bestScore = score
+		}
+	}
+	return best
+}
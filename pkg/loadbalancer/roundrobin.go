@@ -21,11 +21,11 @@ func NewRoundRobinLoadBalancer(reg registry.BackendRegistry) *RoundRobinLoadBala
 func (lb *RoundRobinLoadBalancer) PickBackend() string {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
-	backends := lb.Registry.ListBackends()
+	backends := filterHealthy(lb.Registry.ListBackends())
 	if len(backends) == 0 {
 		return ""
 	}
 	backend := backends[lb.index%len(backends)]
 	lb.index = (lb.index + 1) % len(backends)
-	return backend
+	return backend.URL
 }
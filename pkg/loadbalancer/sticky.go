@@ -0,0 +1,124 @@
+package loadbalancer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// StickyConfig configures the cookie StickyLoadBalancer uses to pin a
+// client to the backend it was previously routed to.
+type StickyConfig struct {
+	CookieName string
+	Secret     string
+	SameSite   http.SameSite
+	Secure     bool
+	HTTPOnly   bool
+	MaxAge     int // seconds
+}
+
+// StickyConfigFromEnv builds a StickyConfig from STICKY_COOKIE_* environment
+// variables, defaulting to a cookie named "openprequal_backend", a one hour
+// max-age, HttpOnly, and SameSite=Lax. Secret defaults to empty, which
+// disables affinity entirely (see StickyLoadBalancer.PickBackend).
+func StickyConfigFromEnv() StickyConfig {
+	cfg := StickyConfig{
+		CookieName: "openprequal_backend",
+		Secret:     os.Getenv("STICKY_COOKIE_SECRET"),
+		SameSite:   http.SameSiteLaxMode,
+		HTTPOnly:   true,
+		MaxAge:     3600,
+	}
+	if v := os.Getenv("STICKY_COOKIE_NAME"); v != "" {
+		cfg.CookieName = v
+	}
+	switch os.Getenv("STICKY_COOKIE_SAMESITE") {
+	case "strict":
+		cfg.SameSite = http.SameSiteStrictMode
+	case "none":
+		cfg.SameSite = http.SameSiteNoneMode
+	case "lax":
+		cfg.SameSite = http.SameSiteLaxMode
+	}
+	if v := os.Getenv("STICKY_COOKIE_SECURE"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Secure = b
+		}
+	}
+	if v := os.Getenv("STICKY_COOKIE_HTTPONLY"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.HTTPOnly = b
+		}
+	}
+	if v := os.Getenv("STICKY_COOKIE_MAXAGE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxAge = n
+		}
+	}
+	return cfg
+}
+
+// StickyLoadBalancer wraps another LoadBalancer with cookie-based session
+// affinity: if the request carries a valid cookie naming a backend that is
+// still registered, that backend is returned directly; otherwise Inner picks
+// and the choice is recorded in a cookie on w.
+//
+// The cookie stores an HMAC-SHA256 hash of the backend URL (keyed by
+// Config.Secret) rather than the URL itself, so a forged cookie can't steer
+// traffic to an arbitrary host and rotating backend hostnames doesn't leak
+// through client cookies.
+type StickyLoadBalancer struct {
+	Inner    LoadBalancer
+	Registry registry.BackendRegistry
+	Config   StickyConfig
+}
+
+// NewStickyLoadBalancer wraps inner with cookie affinity described by cfg.
+// If cfg.Secret is empty, affinity is disabled and PickBackend always
+// delegates to inner.
+func NewStickyLoadBalancer(inner LoadBalancer, reg registry.BackendRegistry, cfg StickyConfig) *StickyLoadBalancer {
+	return &StickyLoadBalancer{Inner: inner, Registry: reg, Config: cfg}
+}
+
+func (lb *StickyLoadBalancer) hash(url string) string {
+	mac := hmac.New(sha256.New, []byte(lb.Config.Secret))
+	mac.Write([]byte(url))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PickBackend returns the backend pinned by r's sticky cookie if it is
+// still registered and live, otherwise it delegates to Inner and pins the
+// result on w for subsequent requests.
+func (lb *StickyLoadBalancer) PickBackend(r *http.Request, w http.ResponseWriter) string {
+	if lb.Config.Secret == "" {
+		return lb.Inner.PickBackend()
+	}
+
+	if cookie, err := r.Cookie(lb.Config.CookieName); err == nil {
+		for _, b := range filterHealthy(lb.Registry.ListBackends()) {
+			if hmac.Equal([]byte(cookie.Value), []byte(lb.hash(b.URL))) {
+				return b.URL
+			}
+		}
+	}
+
+	backend := lb.Inner.PickBackend()
+	if backend == "" {
+		return backend
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     lb.Config.CookieName,
+		Value:    lb.hash(backend),
+		Path:     "/",
+		MaxAge:   lb.Config.MaxAge,
+		Secure:   lb.Config.Secure,
+		HttpOnly: lb.Config.HTTPOnly,
+		SameSite: lb.Config.SameSite,
+	})
+	return backend
+}
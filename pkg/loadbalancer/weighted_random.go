@@ -0,0 +1,171 @@
+package loadbalancer
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// aliasTable is a Vose's alias method table built from a slice of weighted
+// backends, allowing O(1) weighted picks after O(n) preprocessing.
+type aliasTable struct {
+	urls  []string
+	prob  []float64
+	alias []int
+}
+
+// buildAliasTable constructs an aliasTable over backends, treating a
+// non-positive weight as defaultBackendWeight().
+func buildAliasTable(backends []*registry.BackendInfo) *aliasTable {
+	n := len(backends)
+	t := &aliasTable{
+		urls:  make([]string, n),
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+	if n == 0 {
+		return t
+	}
+
+	weights := make([]float64, n)
+	total := 0.0
+	for i, b := range backends {
+		w := b.Weight
+		if w <= 0 {
+			w = defaultBackendWeight()
+		}
+		t.urls[i] = b.URL
+		weights[i] = w
+		total += w
+	}
+
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		t.prob[s] = scaled[s]
+		t.alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	for _, l := range large {
+		t.prob[l] = 1
+	}
+	for _, s := range small {
+		t.prob[s] = 1
+	}
+	return t
+}
+
+func (t *aliasTable) pick() string {
+	n := len(t.urls)
+	if n == 0 {
+		return ""
+	}
+	i := rand.Intn(n)
+	if rand.Float64() < t.prob[i] {
+		return t.urls[i]
+	}
+	return t.urls[t.alias[i]]
+}
+
+// WeightedRandomLoadBalancer picks backends with probability proportional to
+// their registry Weight, using Vose's alias method so each PickBackend call
+// is O(1) once the table has been built. The table is rebuilt lazily
+// whenever the set of backends or their weights change.
+type WeightedRandomLoadBalancer struct {
+	Registry registry.BackendRegistry
+
+	mu    sync.Mutex
+	table *aliasTable
+	sig   string // fingerprint of the backend set/weights the table was built from
+}
+
+func NewWeightedRandomLoadBalancer(reg registry.BackendRegistry) *WeightedRandomLoadBalancer {
+	return &WeightedRandomLoadBalancer{Registry: reg}
+}
+
+// backendSignature fingerprints a set of backends by URL and weight so
+// callers can detect when a cached alias table has gone stale. Backends are
+// sorted by URL first so reordering alone doesn't force a rebuild.
+func backendSignature(backends []*registry.BackendInfo) string {
+	sorted := make([]*registry.BackendInfo, len(backends))
+	copy(sorted, backends)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].URL < sorted[j].URL })
+
+	var sb []byte
+	for _, b := range sorted {
+		sb = append(sb, b.URL...)
+		sb = append(sb, ':')
+		sb = append(sb, strconv.FormatFloat(b.Weight, 'g', -1, 64)...)
+		sb = append(sb, ';')
+	}
+	return string(sb)
+}
+
+func (lb *WeightedRandomLoadBalancer) PickBackend() string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+
+	sig := backendSignature(backends)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.table == nil || lb.sig != sig {
+		lb.table = buildAliasTable(backends)
+		lb.sig = sig
+	}
+	return lb.table.pick()
+}
+
+// WeightedShuffle returns a permutation of backends ordered by decreasing
+// weighted random key (Efraimidis-Spirakis): each backend is keyed by
+// rand.Float64()^(1/weight), so heavier backends tend to sort earlier
+// without ties always favoring the same backend. This is useful for
+// failover ordering, e.g. trying backends in weighted-random order during a
+// retry.
+func WeightedShuffle(backends []*registry.BackendInfo) []*registry.BackendInfo {
+	type keyed struct {
+		backend *registry.BackendInfo
+		key     float64
+	}
+	keys := make([]keyed, len(backends))
+	for i, b := range backends {
+		w := b.Weight
+		if w <= 0 {
+			w = defaultBackendWeight()
+		}
+		keys[i] = keyed{backend: b, key: math.Pow(rand.Float64(), 1/w)}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+	result := make([]*registry.BackendInfo, len(keys))
+	for i, k := range keys {
+		result[i] = k.backend
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package loadbalancer
+
+import (
+	"container/heap"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// defaultBackendWeight returns the weight assumed for a backend that the
+// registry reports with a non-positive Weight, from BACKEND_WEIGHT,
+// defaulting to 1.
+func defaultBackendWeight() float64 {
+	const fallback = 1.0
+	v := os.Getenv("BACKEND_WEIGHT")
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil || parsed <= 0 {
+		return fallback
+	}
+	return parsed
+}
+
+// wrrEntry is one backend's position in the EDF heap.
+type wrrEntry struct {
+	url      string
+	weight   float64
+	deadline float64
+	index    int // maintained by container/heap
+}
+
+// wrrHeap is a min-heap of wrrEntry ordered by deadline.
+type wrrHeap []*wrrEntry
+
+func (h wrrHeap) Len() int           { return len(h) }
+func (h wrrHeap) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+func (h wrrHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i]; h[i].index = i; h[j].index = j }
+func (h *wrrHeap) Push(x interface{}) {
+	e := x.(*wrrEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *wrrHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
+
+// WeightedRoundRobinLoadBalancer selects backends with Earliest Deadline
+// First scheduling: each backend holds a deadline that advances by 1/weight
+// every time it is picked, so heavier backends are picked proportionally
+// more often while still interleaving fairly with lighter ones. Backend
+// weights come from registry.BackendRegistry (Weight <= 0 is treated as
+// defaultBackendWeight()).
+type WeightedRoundRobinLoadBalancer struct {
+	Registry registry.BackendRegistry
+
+	mu              sync.Mutex
+	heap            wrrHeap
+	entries         map[string]*wrrEntry // key: URL, shared with heap
+	currentDeadline float64
+}
+
+func NewWeightedRoundRobinLoadBalancer(reg registry.BackendRegistry) *WeightedRoundRobinLoadBalancer {
+	return &WeightedRoundRobinLoadBalancer{
+		Registry: reg,
+		entries:  make(map[string]*wrrEntry),
+	}
+}
+
+// reconcile adds newly registered backends to the heap and drops ones that
+// are no longer present in the registry, without disturbing the deadlines
+// already scheduled for backends that remain. Must be called with mu held.
+func (lb *WeightedRoundRobinLoadBalancer) reconcile(backends []*registry.BackendInfo) {
+	live := make(map[string]struct{}, len(backends))
+	for _, b := range backends {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = defaultBackendWeight()
+		}
+		live[b.URL] = struct{}{}
+		if e, ok := lb.entries[b.URL]; ok {
+			e.weight = weight
+			continue
+		}
+		e := &wrrEntry{url: b.URL, weight: weight, deadline: lb.currentDeadline + 1/weight}
+		lb.entries[b.URL] = e
+		heap.Push(&lb.heap, e)
+	}
+	for url, e := range lb.entries {
+		if _, ok := live[url]; !ok {
+			heap.Remove(&lb.heap, e.index)
+			delete(lb.entries, url)
+		}
+	}
+}
+
+func (lb *WeightedRoundRobinLoadBalancer) PickBackend() string {
+	backends := filterHealthy(lb.Registry.ListBackends())
+	if len(backends) == 0 {
+		return ""
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.reconcile(backends)
+	if lb.heap.Len() == 0 {
+		return ""
+	}
+
+	e := lb.heap[0]
+	lb.currentDeadline = e.deadline
+	e.deadline = lb.currentDeadline + 1/e.weight
+	heap.Fix(&lb.heap, e.index)
+	return e.url
+}
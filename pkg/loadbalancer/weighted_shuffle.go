@@ -0,0 +1,64 @@
+package loadbalancer
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// WeightedShuffleLoadBalancer orders healthy backends by the "exponential
+// key" trick (Efraimidis-Spirakis: key = -ln(rand())/weight, ascending), so
+// PickBackend returns a weighted-random choice while Order exposes the full
+// ranking to callers that want a weighted failover sequence, e.g. trying
+// backends in order during a retry instead of re-rolling per attempt.
+// Unlike defaultBackendWeight()'s fallback elsewhere in this package, a
+// backend whose Weight is exactly 0 is dropped from the ranking entirely
+// rather than defaulted, so operators can opt a backend out of weighted
+// routing without deregistering it.
+type WeightedShuffleLoadBalancer struct {
+	Registry registry.BackendRegistry
+}
+
+func NewWeightedShuffleLoadBalancer(reg registry.BackendRegistry) *WeightedShuffleLoadBalancer {
+	return &WeightedShuffleLoadBalancer{Registry: reg}
+}
+
+// Order returns healthy, non-zero-weight backends sorted ascending by
+// -ln(rand())/weight, so the first entry is this call's weighted pick and
+// the rest is a weighted-random failover order.
+func (lb *WeightedShuffleLoadBalancer) Order() []*registry.BackendInfo {
+	backends := filterHealthy(lb.Registry.ListBackends())
+
+	type keyed struct {
+		backend *registry.BackendInfo
+		key     float64
+	}
+	keys := make([]keyed, 0, len(backends))
+	for _, b := range backends {
+		if b.Weight == 0 {
+			continue
+		}
+		w := b.Weight
+		if w < 0 {
+			w = defaultBackendWeight()
+		}
+		keys = append(keys, keyed{backend: b, key: -math.Log(rand.Float64()) / w})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key < keys[j].key })
+
+	out := make([]*registry.BackendInfo, len(keys))
+	for i, k := range keys {
+		out[i] = k.backend
+	}
+	return out
+}
+
+func (lb *WeightedShuffleLoadBalancer) PickBackend() string {
+	order := lb.Order()
+	if len(order) == 0 {
+		return ""
+	}
+	return order[0].URL
+}
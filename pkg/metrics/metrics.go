@@ -3,6 +3,7 @@ package metrics
 
 import (
 	"log"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -19,6 +20,7 @@ type MetricsManager struct {
 	latencies []requestLatency
 	rifBins   map[int][]rifLatencyEntry // bin index -> latency entries
 	mu        sync.Mutex
+	logger    *slog.Logger
 }
 
 type requestLatency struct {
@@ -31,10 +33,16 @@ type rifLatencyEntry struct {
 	duration  time.Duration
 }
 
-func NewMetricsManager() *MetricsManager {
+// NewMetricsManager creates a MetricsManager that logs observations through
+// logger. A nil logger falls back to slog.Default().
+func NewMetricsManager(logger *slog.Logger) *MetricsManager {
+	if logger == nil {
+		logger = slog.Default()
+	}
 	return &MetricsManager{
 		latencies: make([]requestLatency, 0, 1000),
 		rifBins:   make(map[int][]rifLatencyEntry),
+		logger:    logger,
 	}
 }
 
@@ -43,7 +51,7 @@ func (m *MetricsManager) IncInFlight() {
 	defer m.mu.Unlock()
 
 	m.inFlight++
-	log.Printf("[MetricsManager] IncInFlight: now %d", m.inFlight)
+	m.logger.Debug("in-flight incremented", slog.Int("in_flight", m.inFlight))
 }
 
 func (m *MetricsManager) DecInFlight() {
@@ -52,7 +60,7 @@ func (m *MetricsManager) DecInFlight() {
 
 	if m.inFlight > 0 {
 		m.inFlight--
-		log.Printf("[MetricsManager] DecInFlight: now %d", m.inFlight)
+		m.logger.Debug("in-flight decremented", slog.Int("in_flight", m.inFlight))
 	}
 }
 
@@ -61,7 +69,7 @@ func (m *MetricsManager) AddLatency(d time.Duration) {
 	defer m.mu.Unlock()
 
 	m.latencies = append(m.latencies, requestLatency{timestamp: time.Now(), duration: d})
-	log.Printf("[MetricsManager] AddLatency: added %v, total count %d", d, len(m.latencies))
+	m.logger.Debug("latency recorded", slog.Duration("latency", d), slog.Int("count", len(m.latencies)))
 }
 
 func (m *MetricsManager) AddLatencyWithRIF(d time.Duration, rif int) {
@@ -75,7 +83,7 @@ func (m *MetricsManager) AddLatencyWithRIF(d time.Duration, rif int) {
 	bin := rif / rifBinSize
 	entry := rifLatencyEntry{timestamp: time.Now(), duration: d}
 	m.rifBins[bin] = append(m.rifBins[bin], entry)
-	log.Printf("[MetricsManager] AddLatencyWithRIF: added %v for RIF=%d (bin=%d), total count %d", d, rif, bin, len(m.latencies))
+	m.logger.Debug("RIF-keyed latency recorded", slog.Duration("latency", d), slog.Int("rif", rif), slog.Int("bin", bin), slog.Int("count", len(m.latencies)))
 }
 
 func (m *MetricsManager) AvgLatencyLast5Min() time.Duration {
@@ -99,12 +107,12 @@ func (m *MetricsManager) AvgLatencyLast5Min() time.Duration {
 	m.latencies = newLatencies // prune old
 
 	if count == 0 {
-		log.Printf("[MetricsManager] AvgLatencyLast5Min: No data in the last 5 minutes")
+		m.logger.Debug("no latency data in the last 5 minutes")
 		return 0 // Return 0 to indicate no data
 	}
 
 	average := sum / time.Duration(count)
-	log.Printf("[MetricsManager] AvgLatencyLast5Min: Average latency = %v", average)
+	m.logger.Debug("average latency over last 5 minutes", slog.Duration("average", average))
 	return average
 }
 
@@ -132,7 +140,7 @@ func (m *MetricsManager) GetAvgLatencyForRIF(targetRIF int) time.Duration {
 			sum += entry.duration
 		}
 		avg := sum / time.Duration(len(entries))
-		log.Printf("[MetricsManager] GetAvgLatencyForRIF: Found data in bin=%d for RIF=%d, avg=%v", targetBin, targetRIF, avg)
+		m.logger.Debug("found latency data for RIF bin", slog.Int("bin", targetBin), slog.Int("target_rif", targetRIF), slog.Duration("avg", avg))
 		return avg
 	}
 
@@ -163,7 +171,7 @@ func (m *MetricsManager) cleanupOldRIFEntries() {
 // interpolateLatencyForRIF finds the closest RIF values and interpolates
 func (m *MetricsManager) interpolateLatencyForRIF(targetRIF int) time.Duration {
 	if len(m.rifBins) == 0 {
-		log.Printf("[MetricsManager] GetAvgLatencyForRIF: No RIF data available for interpolation")
+		m.logger.Debug("no RIF data available for interpolation")
 		return 0
 	}
 
@@ -185,19 +193,19 @@ func (m *MetricsManager) interpolateLatencyForRIF(targetRIF int) time.Duration {
 	// If we only have one side, use that value
 	if hasLower && !hasUpper {
 		avg := m.calculateAvgForRIF(lowerRIF)
-		log.Printf("[MetricsManager] GetAvgLatencyForRIF: Using lower bound RIF=%d for target=%d, avg=%v", lowerRIF, targetRIF, avg)
+		m.logger.Debug("using lower bound RIF for interpolation", slog.Int("lower_rif", lowerRIF), slog.Int("target_rif", targetRIF), slog.Duration("avg", avg))
 		return avg
 	}
 	if hasUpper && !hasLower {
 		avg := m.calculateAvgForRIF(upperRIF)
-		log.Printf("[MetricsManager] GetAvgLatencyForRIF: Using upper bound RIF=%d for target=%d, avg=%v", upperRIF, targetRIF, avg)
+		m.logger.Debug("using upper bound RIF for interpolation", slog.Int("upper_rif", upperRIF), slog.Int("target_rif", targetRIF), slog.Duration("avg", avg))
 		return avg
 	}
 
 	// If we have both bounds and they're the same, return that value
 	if hasLower && hasUpper && lowerRIF == upperRIF {
 		avg := m.calculateAvgForRIF(lowerRIF)
-		log.Printf("[MetricsManager] GetAvgLatencyForRIF: Exact match after bounds search RIF=%d, avg=%v", lowerRIF, avg)
+		m.logger.Debug("exact match after bounds search", slog.Int("rif", lowerRIF), slog.Duration("avg", avg))
 		return avg
 	}
 
@@ -210,12 +218,13 @@ func (m *MetricsManager) interpolateLatencyForRIF(targetRIF int) time.Duration {
 		ratio := float64(targetRIF-lowerRIF) / float64(upperRIF-lowerRIF)
 		interpolated := time.Duration(float64(lowerAvg) + ratio*float64(upperAvg-lowerAvg))
 
-		log.Printf("[MetricsManager] GetAvgLatencyForRIF: Interpolated for RIF=%d between RIF=%d(avg=%v) and RIF=%d(avg=%v), result=%v",
-			targetRIF, lowerRIF, lowerAvg, upperRIF, upperAvg, interpolated)
+		m.logger.Debug("interpolated latency for RIF",
+			slog.Int("target_rif", targetRIF), slog.Int("lower_rif", lowerRIF), slog.Duration("lower_avg", lowerAvg),
+			slog.Int("upper_rif", upperRIF), slog.Duration("upper_avg", upperAvg), slog.Duration("result", interpolated))
 		return interpolated
 	}
 
-	log.Printf("[MetricsManager] GetAvgLatencyForRIF: No suitable data for interpolation")
+	m.logger.Debug("no suitable RIF data for interpolation")
 	return 0
 }
 
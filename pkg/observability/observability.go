@@ -0,0 +1,132 @@
+// Package observability centralizes Prometheus metrics and structured
+// logging for the proxy, probe, and metrics packages so operators get a
+// single /metrics endpoint and consistent, leveled log output instead of
+// scattered log.Printf calls.
+package observability
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	RequestsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "openprequal_requests_total",
+		Help: "Total number of requests proxied.",
+	})
+
+	BackendRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_backend_requests_total",
+		Help: "Total number of requests proxied to a given backend.",
+	}, []string{"backend"})
+
+	BackendRIF = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "openprequal_backend_requests_in_flight",
+		Help: "Last observed in-flight request count for a backend.",
+	}, []string{"backend"})
+
+	BackendLatencySeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "openprequal_backend_latency_seconds",
+		Help:    "Observed per-backend request latency.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"backend"})
+
+	ProbeFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "openprequal_probe_failures_total",
+		Help: "Total number of failed backend probes.",
+	})
+
+	PoolHotColdGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "openprequal_pool_backends",
+		Help: "Number of backends currently classified hot or cold.",
+	}, []string{"status"})
+
+	HealthcheckTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_healthcheck_total",
+		Help: "Total number of active health checks performed per backend, by result.",
+	}, []string{"backend", "result"})
+
+	HealthcheckStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "openprequal_healthcheck_status",
+		Help: "Current active health-check status per backend (1 = healthy, 0 = unhealthy).",
+	}, []string{"backend"})
+
+	PolicySelectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_policy_selections_total",
+		Help: "Total number of backend picks made by each configured selection policy.",
+	}, []string{"policy"})
+
+	PolicyFallbacksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "openprequal_policy_fallbacks_total",
+		Help: "Total number of times a requested selection policy could not be used and fell back to random.",
+	}, []string{"requested_policy"})
+)
+
+// Handler exposes the registered collectors for scraping.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// NewLogger builds a *slog.Logger writing leveled, structured output to
+// stdout. level accepts the usual slog names ("debug", "info", "warn",
+// "error"); an empty string falls back to LOG_LEVEL, then "info".
+func NewLogger(level string) *slog.Logger {
+	if level == "" {
+		level = os.Getenv("LOG_LEVEL")
+	}
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: lvl})
+	return slog.New(handler)
+}
+
+// ObserveBackendPick records that a request was routed to backend.
+func ObserveBackendPick(backend string) {
+	RequestsTotal.Inc()
+	BackendRequestsTotal.WithLabelValues(backend).Inc()
+}
+
+// ObserveBackendMetrics records the latest probed RIF/latency for backend.
+func ObserveBackendMetrics(backend string, requestsInFlight float64, latencyMs float64) {
+	BackendRIF.WithLabelValues(backend).Set(requestsInFlight)
+	BackendLatencySeconds.WithLabelValues(backend).Observe(latencyMs / 1000.0)
+}
+
+// ObservePolicySelection records that policy made a backend pick.
+func ObservePolicySelection(policy string) {
+	PolicySelectionsTotal.WithLabelValues(policy).Inc()
+}
+
+// ObservePolicyFallback records that requestedPolicy could not be used
+// (unregistered, or its factory failed) and the proxy fell back to random.
+func ObservePolicyFallback(requestedPolicy string) {
+	PolicyFallbacksTotal.WithLabelValues(requestedPolicy).Inc()
+}
+
+// SetPoolDistribution reports how many pooled backends are currently hot vs cold.
+func SetPoolDistribution(hot, cold int) {
+	PoolHotColdGauge.WithLabelValues("hot").Set(float64(hot))
+	PoolHotColdGauge.WithLabelValues("cold").Set(float64(cold))
+}
+
+// ObserveHealthcheck records the outcome of a single active health check
+// against backend and the resulting overall status after threshold logic.
+func ObserveHealthcheck(backend string, passed bool, healthy bool) {
+	result := "failure"
+	if passed {
+		result = "success"
+	}
+	HealthcheckTotal.WithLabelValues(backend, result).Inc()
+	status := 0.0
+	if healthy {
+		status = 1.0
+	}
+	HealthcheckStatus.WithLabelValues(backend).Set(status)
+}
@@ -0,0 +1,107 @@
+package probe
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/contracts"
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
+	"github.com/Pranshu258/OpenPrequal/pkg/probe/probepb"
+)
+
+// grpcDialTimeout bounds how long establishing a new connection may take
+// before a probe gives up for this round.
+const grpcDialTimeout = 2 * time.Second
+
+// GRPCProber probes backends over the openprequal.v1.Probe gRPC service
+// instead of polling their HTTP /metrics endpoint. It implements the same
+// ProbeBackend(url) signature as the package-level HTTP helper so callers
+// can switch transports via PROBE_TRANSPORT without touching call sites.
+type GRPCProber struct {
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// NewGRPCProber creates a GRPCProber. A nil logger falls back to slog.Default().
+// Connections are dialed lazily per target and kept open for reuse.
+func NewGRPCProber(logger *slog.Logger) *GRPCProber {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &GRPCProber{logger: logger, conns: make(map[string]*grpc.ClientConn)}
+}
+
+// connFor returns a pooled *grpc.ClientConn for target, dialing one with
+// standard gRPC exponential backoff (base 1s, factor 1.6, jitter 0.2, cap
+// 120s) on first use.
+func (g *GRPCProber) connFor(target string) (*grpc.ClientConn, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if conn, ok := g.conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  1 * time.Second,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   120 * time.Second,
+			},
+		}),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(probepb.JSONContentSubtype)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	g.conns[target] = conn
+	return conn, nil
+}
+
+// ProbeBackend fetches a single metrics snapshot from target over gRPC.
+func (g *GRPCProber) ProbeBackend(target string) (*contracts.ProbeResponse, error) {
+	conn, err := g.connFor(target)
+	if err != nil {
+		g.logger.Warn("failed to dial backend", slog.String("target", target), slog.Any("error", err))
+		observability.ProbeFailuresTotal.Inc()
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), grpcDialTimeout)
+	defer cancel()
+	resp, err := probepb.NewProbeClient(conn).GetMetrics(ctx, &probepb.ProbeRequest{Url: target})
+	if err != nil {
+		g.logger.Warn("error fetching metrics", slog.String("target", target), slog.Any("error", err))
+		observability.ProbeFailuresTotal.Inc()
+		return nil, err
+	}
+	g.logger.Debug("decoded probe response", slog.String("target", target),
+		slog.Int64("requests_in_flight", resp.RequestsInFlight), slog.Float64("average_latency_ms", resp.AverageLatencyMs))
+	return &contracts.ProbeResponse{
+		RequestsInFlight:  resp.RequestsInFlight,
+		AverageLatencyMs:  resp.AverageLatencyMs,
+		RIFKeyedLatencyMs: resp.RIFKeyedLatencyMs,
+	}, nil
+}
+
+// Close releases all pooled connections.
+func (g *GRPCProber) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var firstErr error
+	for target, conn := range g.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(g.conns, target)
+	}
+	return firstErr
+}
@@ -3,12 +3,13 @@ package probe
 import (
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"sort"
 	"time"
 
 	"github.com/Pranshu258/OpenPrequal/pkg/contracts"
+	"github.com/Pranshu258/OpenPrequal/pkg/observability"
 	"gonum.org/v1/gonum/stat"
 )
 
@@ -89,28 +90,70 @@ func NewProbe(historySize int) *Probe {
 	}
 }
 
+// BackendProber abstracts over the HTTP and gRPC probing transports so
+// callers can select one via PROBE_TRANSPORT without changing call sites.
+type BackendProber interface {
+	ProbeBackend(url string) (*contracts.ProbeResponse, error)
+}
+
+// Prober fetches backend metrics over HTTP, logging each step through a
+// *slog.Logger supplied at construction time instead of the package logger.
+type Prober struct {
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewProber creates a Prober. A nil logger falls back to slog.Default().
+func NewProber(logger *slog.Logger) *Prober {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Prober{client: &http.Client{Timeout: 2 * time.Second}, logger: logger}
+}
+
 // ProbeBackend sends a request to the backend /metrics endpoint and returns metrics as ProbeResponse.
-func ProbeBackend(url string) (*contracts.ProbeResponse, error) {
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(url + "/metrics")
+func (p *Prober) ProbeBackend(url string) (*contracts.ProbeResponse, error) {
+	resp, err := p.client.Get(url + "/metrics")
 	if err != nil {
-		log.Printf("[ProbeBackend] Error fetching metrics from %s: %v", url, err)
+		p.logger.Warn("error fetching metrics", slog.String("url", url), slog.Any("error", err))
+		observability.ProbeFailuresTotal.Inc()
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("[ProbeBackend] Error reading response body from %s: %v", url, err)
+		p.logger.Warn("error reading response body", slog.String("url", url), slog.Any("error", err))
+		observability.ProbeFailuresTotal.Inc()
 		return nil, err
 	}
-	log.Printf("[ProbeBackend] Raw response from %s: %s", url, string(bodyBytes))
+	p.logger.Debug("raw probe response", slog.String("url", url), slog.String("body", string(bodyBytes)))
 
 	var probeResp contracts.ProbeResponse
 	if err := json.Unmarshal(bodyBytes, &probeResp); err != nil {
-		log.Printf("[ProbeBackend] Error decoding JSON from %s: %v", url, err)
+		p.logger.Warn("error decoding JSON", slog.String("url", url), slog.Any("error", err))
+		observability.ProbeFailuresTotal.Inc()
 		return nil, err
 	}
-	log.Printf("[ProbeBackend] Decoded ProbeResponse from %s: RequestsInFlight=%d, AverageLatencyMs=%.6f", url, probeResp.RequestsInFlight, probeResp.AverageLatencyMs)
+	p.logger.Debug("decoded probe response", slog.String("url", url),
+		slog.Int64("requests_in_flight", probeResp.RequestsInFlight), slog.Float64("average_latency_ms", probeResp.AverageLatencyMs))
 	return &probeResp, nil
 }
+
+// defaultProber is used by the package-level ProbeBackend helper so existing
+// call sites that don't need a custom logger keep working unchanged.
+var defaultProber = NewProber(nil)
+
+// ProbeBackend is a convenience wrapper around defaultProber.ProbeBackend.
+func ProbeBackend(url string) (*contracts.ProbeResponse, error) {
+	return defaultProber.ProbeBackend(url)
+}
+
+// NewBackendProber builds a BackendProber for transport, which is one of
+// "http" (default) or "grpc". An unrecognized value falls back to "http".
+func NewBackendProber(transport string, logger *slog.Logger) BackendProber {
+	if transport == "grpc" {
+		return NewGRPCProber(logger)
+	}
+	return NewProber(logger)
+}
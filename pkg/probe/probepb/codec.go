@@ -0,0 +1,39 @@
+package probepb
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodec marshals the Probe service's wire types as JSON instead of
+// binary protobuf. ProbeRequest/ProbeResponse/RegisterRequest/RegisterResponse
+// in probe.pb.go are plain structs generated by hand rather than by
+// protoc-gen-go — they carry `protobuf:` tags for documentation but don't
+// implement proto.Message, so grpc-go's built-in "proto" codec (which
+// type-asserts every message to proto.Message) fails on the very first
+// call. It's registered under its own content-subtype name, "json", rather
+// than overriding the default "proto" codec, so any other service sharing a
+// process with this one keeps using real binary protobuf. Callers opt in
+// with grpc.CallContentSubtype(JSONContentSubtype) on the ClientConn/CallOption
+// they use to reach a Probe service; see GRPCProber.connFor.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string { return JSONContentSubtype }
+
+// JSONContentSubtype is the content-subtype callers must request (via
+// grpc.CallContentSubtype) to reach a Probe service whose wire types are
+// marshaled by jsonCodec instead of grpc-go's default proto codec.
+const JSONContentSubtype = "json"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
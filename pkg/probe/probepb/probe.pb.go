@@ -0,0 +1,31 @@
+// Hand-written to mirror the message shapes declared in probe.proto. These
+// structs carry `protobuf:` tags for documentation only — they don't
+// implement proto.Message (no Reset/String/ProtoReflect), so they can't be
+// produced by protoc-gen-go and must not be regenerated from the .proto
+// file; edit them directly instead. They're transported over grpc-go via
+// jsonCodec (see codec.go), not binary protobuf.
+
+package probepb
+
+// ProbeRequest identifies the backend whose metrics are being fetched.
+type ProbeRequest struct {
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+// ProbeResponse mirrors the JSON payload served by a backend's HTTP
+// /metrics endpoint.
+type ProbeResponse struct {
+	RequestsInFlight  int64   `protobuf:"varint,1,opt,name=requests_in_flight,json=requestsInFlight,proto3" json:"requests_in_flight,omitempty"`
+	AverageLatencyMs  float64 `protobuf:"fixed64,2,opt,name=average_latency_ms,json=averageLatencyMs,proto3" json:"average_latency_ms,omitempty"`
+	RIFKeyedLatencyMs float64 `protobuf:"fixed64,3,opt,name=rif_keyed_latency_ms,json=rifKeyedLatencyMs,proto3" json:"rif_keyed_latency_ms,omitempty"`
+}
+
+// RegisterRequest announces a backend URL to the proxy.
+type RegisterRequest struct {
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+// RegisterResponse acknowledges a Register call.
+type RegisterResponse struct {
+	Ok bool `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+}
@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// ConsulDiscoverySource lists healthy instances of a single Consul service
+// via the catalog API. A "weight" service meta field, if present and
+// numeric, becomes the backend's Weight; everything else uses the registry
+// default of 1.
+type ConsulDiscoverySource struct {
+	client     *consulapi.Client
+	service    string
+	tag        string
+	scheme     string // "http" or "https", prefixed onto each instance's URL
+	datacenter string
+}
+
+// NewConsulDiscoverySource builds a ConsulDiscoverySource for service
+// (optionally filtered by tag) against the Consul agent at cfg.Address. An
+// empty scheme defaults to "http".
+func NewConsulDiscoverySource(cfg *consulapi.Config, service, tag, scheme string) (*ConsulDiscoverySource, error) {
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("registry: consul client: %w", err)
+	}
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &ConsulDiscoverySource{client: client, service: service, tag: tag, scheme: scheme}, nil
+}
+
+func (s *ConsulDiscoverySource) Name() string { return "consul" }
+
+// Discover queries Consul's catalog for healthy instances of s.service.
+// Instances failing any health check are excluded, matching how the rest of
+// this package only routes to backends an active checker has passed.
+func (s *ConsulDiscoverySource) Discover(ctx context.Context) ([]DiscoveredBackend, error) {
+	entries, _, err := s.client.Health().Service(s.service, s.tag, true, (&consulapi.QueryOptions{Datacenter: s.datacenter}).WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("registry: consul health.Service(%s): %w", s.service, err)
+	}
+
+	out := make([]DiscoveredBackend, 0, len(entries))
+	for _, e := range entries {
+		addr := e.Service.Address
+		if addr == "" {
+			addr = e.Node.Address
+		}
+		weight := 1.0
+		if raw, ok := e.Service.Meta["weight"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed > 0 {
+				weight = parsed
+			}
+		}
+		out = append(out, DiscoveredBackend{
+			URL:    fmt.Sprintf("%s://%s:%d", s.scheme, addr, e.Service.Port),
+			Weight: weight,
+			Zone:   e.Node.Datacenter,
+		})
+	}
+	return out, nil
+}
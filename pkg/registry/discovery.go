@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// DiscoveredBackend is a single backend instance a DiscoverySource reports.
+// Weight, Zone, and Revision are translated onto the matching BackendInfo
+// once registered.
+type DiscoveredBackend struct {
+	URL      string
+	Weight   float64
+	Zone     string
+	Revision string
+}
+
+// DiscoverySource fetches the current backend membership from an external
+// system. Discover is called on every poll tick; sources with a native
+// streaming or blocking-query API (Consul, etcd, Kubernetes watches) should
+// still return promptly from a single call and let their own client handle
+// long-polling between calls rather than blocking Discover itself.
+type DiscoverySource interface {
+	// Name identifies the source for logging, e.g. "consul", "etcd", "k8s", "dns".
+	Name() string
+	Discover(ctx context.Context) ([]DiscoveredBackend, error)
+}
+
+// defaultDiscoveryInterval is used when NewDiscoveryBackendRegistry is
+// given a non-positive interval.
+const defaultDiscoveryInterval = 10 * time.Second
+
+// DiscoveryBackendRegistry wraps an InMemoryBackendRegistry whose membership
+// is driven entirely by polling a DiscoverySource, rather than by
+// RegisterBackend/DeregisterBackend calls from a heartbeat endpoint. This is
+// the shared plumbing behind the Consul, etcd, Kubernetes, and DNS SRV
+// adapters in this package: each only has to implement DiscoverySource, and
+// gets ListBackends/Watch/hot-cold classification/etc. for free from the
+// embedded InMemoryBackendRegistry.
+type DiscoveryBackendRegistry struct {
+	*InMemoryBackendRegistry
+	source   DiscoverySource
+	interval time.Duration
+	cancel   context.CancelFunc
+}
+
+// NewDiscoveryBackendRegistry builds a registry that polls source every
+// interval (<= 0 defaults to 10s). Call Start to begin polling.
+func NewDiscoveryBackendRegistry(source DiscoverySource, interval time.Duration) *DiscoveryBackendRegistry {
+	if interval <= 0 {
+		interval = defaultDiscoveryInterval
+	}
+	return &DiscoveryBackendRegistry{
+		InMemoryBackendRegistry: NewInMemoryBackendRegistry(nil),
+		source:                  source,
+		interval:                interval,
+	}
+}
+
+// Start runs an initial sync and then polls source every interval until ctx
+// is done or Stop is called.
+func (r *DiscoveryBackendRegistry) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	go r.run(ctx)
+}
+
+// Stop cancels the background poll loop started by Start.
+func (r *DiscoveryBackendRegistry) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+func (r *DiscoveryBackendRegistry) run(ctx context.Context) {
+	r.sync(ctx)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.sync(ctx)
+		}
+	}
+}
+
+// sync reconciles the embedded registry's membership with a single
+// DiscoverySource.Discover call: backends no longer reported are
+// deregistered, new ones registered, and every reported backend's
+// weight/zone/revision metadata refreshed.
+func (r *DiscoveryBackendRegistry) sync(ctx context.Context) {
+	found, err := r.source.Discover(ctx)
+	if err != nil {
+		log.Printf("registry: %s discovery failed: %v", r.source.Name(), err)
+		return
+	}
+
+	seen := make(map[string]struct{}, len(found))
+	for _, b := range found {
+		seen[b.URL] = struct{}{}
+		r.RegisterBackendWithWeight(b.URL, b.Weight)
+		r.UpdateBackend(b.URL, func(info *BackendInfo) {
+			info.Zone = b.Zone
+			info.Revision = b.Revision
+		})
+	}
+	for _, existing := range r.ListBackends() {
+		if _, ok := seen[existing.URL]; !ok {
+			r.DeregisterBackend(existing.URL)
+		}
+	}
+}
+
+// CompositeBackendRegistry merges several BackendRegistry sources (e.g. a
+// self-registered in-memory registry and a Kubernetes-discovered one) into a
+// single read view, so a proxy can serve both kinds of backend pools at
+// once. Writes (RegisterBackend, UpdateMetrics, SetHealthy, ...) go to the
+// first source, which is expected to be the self-registered, writable one;
+// the remaining sources are treated as read-only membership feeds.
+type CompositeBackendRegistry struct {
+	primary BackendRegistry
+	sources []BackendRegistry
+}
+
+// NewCompositeBackendRegistry builds a CompositeBackendRegistry that merges
+// primary (which receives all writes) with any additional read-only
+// sources, de-duplicating backends that appear in more than one by URL
+// (primary wins on conflict).
+func NewCompositeBackendRegistry(primary BackendRegistry, sources ...BackendRegistry) *CompositeBackendRegistry {
+	return &CompositeBackendRegistry{primary: primary, sources: sources}
+}
+
+// ListBackends returns the union of every source's backends, sorted by URL,
+// with primary's entry winning when the same URL appears in more than one
+// source.
+func (c *CompositeBackendRegistry) ListBackends() []*BackendInfo {
+	merged := make(map[string]*BackendInfo)
+	for _, b := range c.primary.ListBackends() {
+		merged[b.URL] = b
+	}
+	for _, src := range c.sources {
+		for _, b := range src.ListBackends() {
+			if _, dup := merged[b.URL]; !dup {
+				merged[b.URL] = b
+			}
+		}
+	}
+	urls := make([]string, 0, len(merged))
+	for url := range merged {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	out := make([]*BackendInfo, 0, len(urls))
+	for _, url := range urls {
+		out = append(out, merged[url])
+	}
+	return out
+}
+
+func (c *CompositeBackendRegistry) RegisterBackend(url string) { c.primary.RegisterBackend(url) }
+
+func (c *CompositeBackendRegistry) RegisterBackendWithWeight(url string, weight float64) {
+	c.primary.RegisterBackendWithWeight(url, weight)
+}
+
+func (c *CompositeBackendRegistry) SetHealthy(url string, healthy bool) {
+	c.primary.SetHealthy(url, healthy)
+}
+
+func (c *CompositeBackendRegistry) DeregisterBackend(url string) { c.primary.DeregisterBackend(url) }
+
+func (c *CompositeBackendRegistry) UpdateMetrics(url string, requestsInFlight int64, averageLatencyMs float64, rifKeyedLatencyMs float64) {
+	c.primary.UpdateMetrics(url, requestsInFlight, averageLatencyMs, rifKeyedLatencyMs)
+}
+
+// Watch streams primary's events only; the read-only discovery sources are
+// expected to be polled at a low enough rate (and consulted via
+// ListBackends directly) that subscribing to per-source churn isn't useful.
+func (c *CompositeBackendRegistry) Watch(ctx context.Context) <-chan Event {
+	return c.primary.Watch(ctx)
+}
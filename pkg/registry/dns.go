@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// DNSDiscoverySource lists backends from a DNS SRV record, the lowest-common
+// -denominator discovery mechanism for environments without Consul, etcd, or
+// Kubernetes (e.g. plain container orchestrators that publish SRV records).
+type DNSDiscoverySource struct {
+	resolver *net.Resolver
+	service  string // SRV "service" part, e.g. "http"
+	proto    string // SRV "proto" part, e.g. "tcp"
+	name     string // domain name to query, e.g. "backends.example.internal"
+	scheme   string // "http" or "https", prefixed onto each instance's URL
+}
+
+// NewDNSDiscoverySource builds a DNSDiscoverySource that looks up
+// _service._proto.name via resolver (net.DefaultResolver if nil). An empty
+// scheme defaults to "http".
+func NewDNSDiscoverySource(resolver *net.Resolver, service, proto, name, scheme string) *DNSDiscoverySource {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &DNSDiscoverySource{resolver: resolver, service: service, proto: proto, name: name, scheme: scheme}
+}
+
+func (s *DNSDiscoverySource) Name() string { return "dns" }
+
+// Discover resolves the SRV record and, for each target, resolves its
+// address so the returned URL carries an IP rather than leaving a second DNS
+// lookup to happen per-request on the hot path.
+func (s *DNSDiscoverySource) Discover(ctx context.Context) ([]DiscoveredBackend, error) {
+	_, srvs, err := s.resolver.LookupSRV(ctx, s.service, s.proto, s.name)
+	if err != nil {
+		return nil, fmt.Errorf("registry: dns LookupSRV(%s, %s, %s): %w", s.service, s.proto, s.name, err)
+	}
+
+	out := make([]DiscoveredBackend, 0, len(srvs))
+	for _, srv := range srvs {
+		addrs, err := s.resolver.LookupHost(ctx, srv.Target)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		out = append(out, DiscoveredBackend{
+			URL: fmt.Sprintf("%s://%s:%s", s.scheme, addrs[0], strconv.Itoa(int(srv.Port))),
+		})
+	}
+	return out, nil
+}
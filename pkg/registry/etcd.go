@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdBackendEntry is the JSON payload each backend's etcd key is expected
+// to hold, mirroring redisEntry's shape so operators migrating between the
+// two registries don't need to change what they write.
+type etcdBackendEntry struct {
+	URL      string  `json:"url"`
+	Weight   float64 `json:"weight"`
+	Zone     string  `json:"zone"`
+	Revision string  `json:"revision"`
+}
+
+// EtcdDiscoverySource lists backends published as JSON values under a key
+// prefix in etcd v3 (e.g. written by a sidecar on startup and removed, via
+// a lease, on shutdown or crash).
+type EtcdDiscoverySource struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcdDiscoverySource builds an EtcdDiscoverySource that lists every key
+// under prefix on each Discover call.
+func NewEtcdDiscoverySource(client *clientv3.Client, prefix string) *EtcdDiscoverySource {
+	return &EtcdDiscoverySource{client: client, prefix: prefix}
+}
+
+func (s *EtcdDiscoverySource) Name() string { return "etcd" }
+
+// Discover issues a single prefix Get; entries whose value doesn't parse as
+// etcdBackendEntry JSON, or whose url is empty, are skipped rather than
+// failing the whole call.
+func (s *EtcdDiscoverySource) Discover(ctx context.Context) ([]DiscoveredBackend, error) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("registry: etcd Get(%s): %w", s.prefix, err)
+	}
+
+	out := make([]DiscoveredBackend, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var entry etcdBackendEntry
+		if err := json.Unmarshal(kv.Value, &entry); err != nil || entry.URL == "" {
+			continue
+		}
+		weight := entry.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		out = append(out, DiscoveredBackend{
+			URL:      entry.URL,
+			Weight:   weight,
+			Zone:     entry.Zone,
+			Revision: entry.Revision,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// KubernetesDiscoverySource lists ready endpoints of a single Service via its
+// EndpointSlices, which is the API Kubernetes itself uses to feed kube-proxy
+// and is preferred over the older Endpoints resource for large services.
+type KubernetesDiscoverySource struct {
+	client    kubernetes.Interface
+	namespace string
+	service   string
+	portName  string // endpoint port name to use; empty selects the first port
+	scheme    string // "http" or "https", prefixed onto each instance's URL
+}
+
+// NewKubernetesDiscoverySource builds a KubernetesDiscoverySource for the
+// named Service in namespace. An empty scheme defaults to "http"; an empty
+// portName uses each EndpointSlice's first listed port.
+func NewKubernetesDiscoverySource(client kubernetes.Interface, namespace, service, portName, scheme string) *KubernetesDiscoverySource {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &KubernetesDiscoverySource{client: client, namespace: namespace, service: service, portName: portName, scheme: scheme}
+}
+
+func (s *KubernetesDiscoverySource) Name() string { return "k8s" }
+
+// Discover lists the EndpointSlices labelled for s.service and returns one
+// DiscoveredBackend per ready address, with Zone taken from the endpoint's
+// reported zone hint when present.
+func (s *KubernetesDiscoverySource) Discover(ctx context.Context) ([]DiscoveredBackend, error) {
+	slices, err := s.client.DiscoveryV1().EndpointSlices(s.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("kubernetes.io/service-name=%s", s.service),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("registry: k8s list endpointslices for %s/%s: %w", s.namespace, s.service, err)
+	}
+
+	var out []DiscoveredBackend
+	for _, slice := range slices.Items {
+		port := s.matchPort(slice.Ports)
+		if port == 0 {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			zone := ""
+			if ep.Zone != nil {
+				zone = *ep.Zone
+			}
+			for _, addr := range ep.Addresses {
+				out = append(out, DiscoveredBackend{
+					URL:  fmt.Sprintf("%s://%s:%s", s.scheme, addr, strconv.Itoa(int(port))),
+					Zone: zone,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+// matchPort picks s.portName from ports, or the first port if portName is
+// empty or not found.
+func (s *KubernetesDiscoverySource) matchPort(ports []discoveryv1.EndpointPort) int32 {
+	if len(ports) == 0 {
+		return 0
+	}
+	if s.portName == "" {
+		return derefInt32(ports[0].Port)
+	}
+	for _, p := range ports {
+		if p.Name != nil && *p.Name == s.portName {
+			return derefInt32(p.Port)
+		}
+	}
+	return derefInt32(ports[0].Port)
+}
+
+func derefInt32(p *int32) int32 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
@@ -0,0 +1,283 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/probe"
+)
+
+// redisEntry is the JSON payload stored per-backend in the registry hash and
+// broadcast over pub/sub so every proxy instance converges on the same view.
+type redisEntry struct {
+	URL               string    `json:"url"`
+	RequestsInFlight  int64     `json:"requests_in_flight"`
+	AverageLatencyMs  float64   `json:"average_latency_ms"`
+	RIFKeyedLatencyMs float64   `json:"rif_keyed_latency_ms"`
+	HotCold           string    `json:"hot_cold"`
+	LastHeartbeat     time.Time `json:"last_heartbeat"`
+	Weight            float64   `json:"weight"`
+	Healthy           bool      `json:"healthy"`
+}
+
+// redisMessage is published on the registry's pub/sub channel on every
+// register/deregister/heartbeat so peer proxies can react without polling.
+type redisMessage struct {
+	Type  EventType  `json:"type"`
+	Entry redisEntry `json:"entry"`
+}
+
+// defaultRedisTTL is how long a backend may go without a heartbeat before
+// it is evicted from the hash by the reaper loop.
+const defaultRedisTTL = 30 * time.Second
+
+// RedisBackendRegistry implements BackendRegistry on top of a Redis hash
+// (for the shared membership/metrics snapshot) and a Redis pub/sub channel
+// (for register/deregister/heartbeat notifications), so multiple proxy
+// instances behind the same Redis converge on a consistent view of backends.
+type RedisBackendRegistry struct {
+	client   *redis.Client
+	key      string
+	chanName string
+	ttl      time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewRedisBackendRegistry connects to the Redis instance at addr and stores
+// backend state in the hash named key (pub/sub uses key+":events"). The
+// returned registry runs a background reaper that evicts entries whose
+// heartbeat is older than defaultRedisTTL.
+func NewRedisBackendRegistry(addr, key string) *RedisBackendRegistry {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RedisBackendRegistry{
+		client:   client,
+		key:      key,
+		chanName: key + ":events",
+		ttl:      defaultRedisTTL,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	go r.reapLoop()
+	return r
+}
+
+func (r *RedisBackendRegistry) publish(msg redisMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[RedisBackendRegistry] failed to marshal event: %v", err)
+		return
+	}
+	if err := r.client.Publish(r.ctx, r.chanName, data).Err(); err != nil {
+		log.Printf("[RedisBackendRegistry] failed to publish event: %v", err)
+	}
+}
+
+func (r *RedisBackendRegistry) store(e redisEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("[RedisBackendRegistry] failed to marshal entry for %s: %v", e.URL, err)
+		return
+	}
+	if err := r.client.HSet(r.ctx, r.key, e.URL, data).Err(); err != nil {
+		log.Printf("[RedisBackendRegistry] failed to store entry for %s: %v", e.URL, err)
+	}
+}
+
+func (r *RedisBackendRegistry) RegisterBackend(url string) {
+	r.RegisterBackendWithWeight(url, 1)
+}
+
+func (r *RedisBackendRegistry) RegisterBackendWithWeight(url string, weight float64) {
+	if weight <= 0 {
+		weight = 1
+	}
+	e := redisEntry{URL: url, HotCold: "cold", LastHeartbeat: time.Now(), Weight: weight, Healthy: true}
+	r.store(e)
+	r.publish(redisMessage{Type: EventRegistered, Entry: e})
+}
+
+// SetHealthy writes the active health checker's latest verdict for url,
+// carrying its other fields forward from the last stored entry so a health
+// check doesn't clobber metrics/weight written by the heartbeat path.
+func (r *RedisBackendRegistry) SetHealthy(url string, healthy bool) {
+	raw, err := r.client.HGet(r.ctx, r.key, url).Result()
+	if err != nil {
+		return
+	}
+	var e redisEntry
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return
+	}
+	e.Healthy = healthy
+	r.store(e)
+	r.publish(redisMessage{Type: EventHeartbeat, Entry: e})
+}
+
+func (r *RedisBackendRegistry) DeregisterBackend(url string) {
+	if err := r.client.HDel(r.ctx, r.key, url).Err(); err != nil {
+		log.Printf("[RedisBackendRegistry] failed to remove entry for %s: %v", url, err)
+	}
+	r.publish(redisMessage{Type: EventDeregistered, Entry: redisEntry{URL: url}})
+}
+
+// UpdateMetrics writes the latest probe results for url and republishes a
+// heartbeat event, which both refreshes the TTL and lets peers update their
+// local view. Hot/cold is derived from a single-sample comparison against
+// QRIF_THRESHOLD-style reasoning isn't available cluster-wide here, so it
+// falls back to the simple rule the in-memory registry used before probe
+// history existed: hot if in-flight requests exceed the running average.
+func (r *RedisBackendRegistry) UpdateMetrics(url string, requestsInFlight int64, averageLatencyMs float64, rifKeyedLatencyMs float64) {
+	// Weight and Healthy aren't part of this call, so carry the previously
+	// stored values forward instead of letting every heartbeat reset them to
+	// their zero values.
+	weight := 1.0
+	healthy := true
+	if raw, err := r.client.HGet(r.ctx, r.key, url).Result(); err == nil {
+		var existing redisEntry
+		if err := json.Unmarshal([]byte(raw), &existing); err == nil {
+			if existing.Weight > 0 {
+				weight = existing.Weight
+			}
+			healthy = existing.Healthy
+		}
+	}
+
+	e := redisEntry{
+		URL:               url,
+		RequestsInFlight:  requestsInFlight,
+		AverageLatencyMs:  averageLatencyMs,
+		RIFKeyedLatencyMs: rifKeyedLatencyMs,
+		LastHeartbeat:     time.Now(),
+		Weight:            weight,
+		Healthy:           healthy,
+	}
+	if float64(requestsInFlight) > averageLatencyMs {
+		e.HotCold = "hot"
+	} else {
+		e.HotCold = "cold"
+	}
+	r.store(e)
+	r.publish(redisMessage{Type: EventHeartbeat, Entry: e})
+}
+
+func (r *RedisBackendRegistry) ListBackends() []*BackendInfo {
+	raw, err := r.client.HGetAll(r.ctx, r.key).Result()
+	if err != nil {
+		log.Printf("[RedisBackendRegistry] failed to list backends: %v", err)
+		return nil
+	}
+	urls := make([]string, 0, len(raw))
+	for url := range raw {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	result := make([]*BackendInfo, 0, len(urls))
+	for _, url := range urls {
+		var e redisEntry
+		if err := json.Unmarshal([]byte(raw[url]), &e); err != nil {
+			continue
+		}
+		result = append(result, &BackendInfo{
+			URL:               e.URL,
+			RequestsInFlight:  e.RequestsInFlight,
+			AverageLatencyMs:  e.AverageLatencyMs,
+			RIFKeyedLatencyMs: e.RIFKeyedLatencyMs,
+			HotCold:           e.HotCold,
+			Probe:             probe.NewProbe(1), // no shared history across instances
+			Weight:            e.Weight,
+			Healthy:           e.Healthy,
+		})
+	}
+	return result
+}
+
+// Watch subscribes to the registry's pub/sub channel and translates
+// messages into Events until ctx is done.
+func (r *RedisBackendRegistry) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event, watchBufferSize)
+	sub := r.client.Subscribe(ctx, r.chanName)
+
+	go func() {
+		defer close(ch)
+		defer sub.Close()
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var rm redisMessage
+				if err := json.Unmarshal([]byte(m.Payload), &rm); err != nil {
+					continue
+				}
+				ev := Event{
+					Type: rm.Type,
+					URL:  rm.Entry.URL,
+					Backend: &BackendInfo{
+						URL:               rm.Entry.URL,
+						RequestsInFlight:  rm.Entry.RequestsInFlight,
+						AverageLatencyMs:  rm.Entry.AverageLatencyMs,
+						RIFKeyedLatencyMs: rm.Entry.RIFKeyedLatencyMs,
+						HotCold:           rm.Entry.HotCold,
+						Weight:            rm.Entry.Weight,
+						Healthy:           rm.Entry.Healthy,
+					},
+				}
+				select {
+				case ch <- ev:
+				default:
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// reapLoop evicts backends whose last heartbeat is older than r.ttl so a
+// crashed or partitioned backend eventually disappears from every proxy's
+// view even if it never sent an explicit deregister.
+func (r *RedisBackendRegistry) reapLoop() {
+	ticker := time.NewTicker(r.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			raw, err := r.client.HGetAll(r.ctx, r.key).Result()
+			if err != nil {
+				continue
+			}
+			now := time.Now()
+			for url, v := range raw {
+				var e redisEntry
+				if err := json.Unmarshal([]byte(v), &e); err != nil {
+					continue
+				}
+				if now.Sub(e.LastHeartbeat) > r.ttl {
+					log.Printf("[RedisBackendRegistry] evicting stale backend %s (no heartbeat for %s)", url, now.Sub(e.LastHeartbeat))
+					r.DeregisterBackend(url)
+				}
+			}
+		}
+	}
+}
+
+// Close stops the reaper loop and releases the underlying Redis client.
+func (r *RedisBackendRegistry) Close() error {
+	r.cancel()
+	return r.client.Close()
+}
@@ -1,18 +1,55 @@
 package registry
 
 import (
+	"context"
 	"sort"
 	"sync"
 
+	"github.com/Pranshu258/OpenPrequal/pkg/metrics"
 	"github.com/Pranshu258/OpenPrequal/pkg/probe"
 )
 
+// EventType enumerates the kinds of registry changes delivered via Watch.
+type EventType string
+
+const (
+	EventRegistered   EventType = "registered"
+	EventDeregistered EventType = "deregistered"
+	EventHeartbeat    EventType = "heartbeat"
+)
+
+// Event describes a single registry membership or metrics change.
+type Event struct {
+	Type    EventType
+	URL     string
+	Backend *BackendInfo
+}
+
+// watchBufferSize bounds how many events a Watch subscriber can lag behind
+// before new events are dropped for it.
+const watchBufferSize = 32
+
 // BackendRegistry defines the interface for backend management
-// (listing, adding, removing, etc.)
+// (listing, adding, removing, metrics updates) and for observing membership
+// changes without polling.
 type BackendRegistry interface {
 	ListBackends() []*BackendInfo
 	RegisterBackend(url string)
-	RemoveBackend(url string)
+	// RegisterBackendWithWeight registers url (or, if already registered,
+	// just updates its weight) with an explicit relative weight for
+	// weight-aware load balancers such as WeightedRoundRobinLoadBalancer.
+	// RegisterBackend is equivalent to RegisterBackendWithWeight(url, 1).
+	RegisterBackendWithWeight(url string, weight float64)
+	// SetHealthy records the active health checker's latest verdict for url,
+	// so load balancers that filter on BackendInfo.Healthy stay in sync. It
+	// is a no-op if url is not registered.
+	SetHealthy(url string, healthy bool)
+	DeregisterBackend(url string)
+	UpdateMetrics(url string, requestsInFlight int64, averageLatencyMs float64, rifKeyedLatencyMs float64)
+	// Watch returns a channel of Events; it is closed once ctx is done.
+	// Subscribers that fall behind have events dropped rather than blocking
+	// the registry.
+	Watch(ctx context.Context) <-chan Event
 }
 
 type BackendInfo struct {
@@ -22,11 +59,21 @@ type BackendInfo struct {
 	RIFKeyedLatencyMs float64
 	HotCold           string // "hot" or "cold"
 	Probe             *probe.Probe
+	Weight            float64 // relative share for weight-aware balancers; RegisterBackendWithWeight stores <= 0 as 1, NewInMemoryBackendRegistry leaves it 0
+	Healthy           bool    // set by an active health checker; true until checked otherwise
+	// Zone and Revision carry metadata translated from an external service
+	// discovery source (see DiscoveryBackendRegistry); both are empty for
+	// backends registered via heartbeat or RegisterBackend.
+	Zone     string
+	Revision string
 }
 
 type InMemoryBackendRegistry struct {
 	mu       sync.RWMutex
 	Backends map[string]*BackendInfo // key: URL
+
+	subsMu sync.Mutex
+	subs   map[chan Event]struct{}
 }
 
 func NewInMemoryBackendRegistry(urls []string) *InMemoryBackendRegistry {
@@ -36,9 +83,10 @@ func NewInMemoryBackendRegistry(urls []string) *InMemoryBackendRegistry {
 			URL:     url,
 			HotCold: "cold",
 			Probe:   probe.NewProbe(100), // history size 10, adjust as needed
+			Healthy: true,
 		}
 	}
-	return &InMemoryBackendRegistry{Backends: backends}
+	return &InMemoryBackendRegistry{Backends: backends, subs: make(map[chan Event]struct{})}
 }
 
 func (r *InMemoryBackendRegistry) ListBackends() []*BackendInfo {
@@ -58,20 +106,109 @@ func (r *InMemoryBackendRegistry) ListBackends() []*BackendInfo {
 }
 
 func (r *InMemoryBackendRegistry) RegisterBackend(url string) {
+	r.RegisterBackendWithWeight(url, 1)
+}
+
+func (r *InMemoryBackendRegistry) RegisterBackendWithWeight(url string, weight float64) {
+	if weight <= 0 {
+		weight = 1
+	}
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, exists := r.Backends[url]; exists {
-		return // already registered
+	if b, exists := r.Backends[url]; exists {
+		b.Weight = weight
+		r.mu.Unlock()
+		return
 	}
-	r.Backends[url] = &BackendInfo{
+	b := &BackendInfo{
 		URL:     url,
 		HotCold: "cold",
 		Probe:   probe.NewProbe(10), // history size 10, adjust as needed
+		Weight:  weight,
+		Healthy: true,
 	}
+	r.Backends[url] = b
+	r.mu.Unlock()
+	r.publish(Event{Type: EventRegistered, URL: url, Backend: b})
 }
 
-func (r *InMemoryBackendRegistry) RemoveBackend(url string) {
+func (r *InMemoryBackendRegistry) DeregisterBackend(url string) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	delete(r.Backends, url)
+	r.mu.Unlock()
+	r.publish(Event{Type: EventDeregistered, URL: url})
+}
+
+// UpdateBackend mutates the BackendInfo for url under the registry lock.
+// It is kept for callers (e.g. the probe subsystem) that need to update
+// several fields atomically; prefer UpdateMetrics for simple metric writes.
+func (r *InMemoryBackendRegistry) UpdateBackend(url string, fn func(b *BackendInfo)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.Backends[url]
+	if !ok {
+		return
+	}
+	fn(b)
+}
+
+// UpdateMetrics records the latest probe results for url, refreshes its
+// hot/cold classification, and notifies Watch subscribers.
+func (r *InMemoryBackendRegistry) UpdateMetrics(url string, requestsInFlight int64, averageLatencyMs float64, rifKeyedLatencyMs float64) {
+	r.mu.Lock()
+	b, ok := r.Backends[url]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	b.RequestsInFlight = requestsInFlight
+	b.AverageLatencyMs = averageLatencyMs
+	b.RIFKeyedLatencyMs = rifKeyedLatencyMs
+	rif := float64(requestsInFlight)
+	b.Probe.AddRIF(rif)
+	b.Probe.AddLatency(rifKeyedLatencyMs)
+	b.HotCold = b.Probe.Status(rif)
+	r.mu.Unlock()
+
+	metrics.LogProbeUpdate(url, requestsInFlight, averageLatencyMs, rifKeyedLatencyMs, b.HotCold)
+	r.publish(Event{Type: EventHeartbeat, URL: url, Backend: b})
+}
+
+// SetHealthy flips the Healthy flag of url, as maintained by this registry.
+func (r *InMemoryBackendRegistry) SetHealthy(url string, healthy bool) {
+	r.mu.Lock()
+	b, ok := r.Backends[url]
+	if ok {
+		b.Healthy = healthy
+	}
+	r.mu.Unlock()
+}
+
+func (r *InMemoryBackendRegistry) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event, watchBufferSize)
+	r.subsMu.Lock()
+	r.subs[ch] = struct{}{}
+	r.subsMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		r.subsMu.Lock()
+		delete(r.subs, ch)
+		r.subsMu.Unlock()
+		close(ch)
+	}()
+
+	return ch
+}
+
+// publish fans an event out to all Watch subscribers without blocking;
+// a subscriber that is too slow to keep up simply drops the event.
+func (r *InMemoryBackendRegistry) publish(ev Event) {
+	r.subsMu.Lock()
+	defer r.subsMu.Unlock()
+	for ch := range r.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
 }
@@ -1,7 +1,11 @@
 package test
 
 import (
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Pranshu258/OpenPrequal/pkg/loadbalancer"
 	"github.com/Pranshu258/OpenPrequal/pkg/registry"
@@ -61,3 +65,232 @@ func TestLeastLatencyLoadBalancer_PickBackend(t *testing.T) {
 		t.Errorf("LeastLatencyLoadBalancer picked %v, want 'y'", picked)
 	}
 }
+
+// Test EWMADecay against the expected closed-form values for a few seeded
+// elapsed durations relative to halfLife.
+func TestEWMADecay(t *testing.T) {
+	halfLife := 10 * time.Second
+	cases := []struct {
+		name    string
+		elapsed time.Duration
+		want    float64
+	}{
+		{"zero elapsed keeps old value", 0, 100.0},
+		{"one half-life halves old's weight", halfLife, 75.0}, // 0.5*100 + 0.5*50
+		{"two half-lives leaves old at 25% weight", 2 * halfLife, 62.5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := loadbalancer.EWMADecay(100.0, 50.0, c.elapsed, halfLife)
+			if math.Abs(got-c.want) > 1e-9 {
+				t.Errorf("EWMADecay(100, 50, %v, %v) = %v, want %v", c.elapsed, halfLife, got, c.want)
+			}
+		})
+	}
+}
+
+// TestWeightedShuffleLoadBalancer_SkipsZeroWeight verifies a backend with
+// Weight == 0 never appears in Order, while positive-weight backends always
+// do.
+func TestWeightedShuffleLoadBalancer_SkipsZeroWeight(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a", "b"})
+	reg.Backends["a"].Weight = 0
+	reg.Backends["b"].Weight = 1
+	lb := loadbalancer.NewWeightedShuffleLoadBalancer(reg)
+	for i := 0; i < 50; i++ {
+		order := lb.Order()
+		if len(order) != 1 || order[0].URL != "b" {
+			t.Fatalf("Order() = %v, want only 'b'", order)
+		}
+	}
+}
+
+// TestWeightedShuffleLoadBalancer_Distribution checks that PickBackend's
+// empirical pick frequency is roughly proportional to weight, within a
+// generous tolerance to keep the test non-flaky.
+func TestWeightedShuffleLoadBalancer_Distribution(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a", "b"})
+	reg.Backends["a"].Weight = 3
+	reg.Backends["b"].Weight = 1
+	lb := loadbalancer.NewWeightedShuffleLoadBalancer(reg)
+
+	const trials = 20000
+	counts := map[string]int{}
+	for i := 0; i < trials; i++ {
+		counts[lb.PickBackend()]++
+	}
+
+	wantA := float64(trials) * 3.0 / 4.0
+	gotA := float64(counts["a"])
+	if math.Abs(gotA-wantA)/wantA > 0.1 {
+		t.Errorf("backend 'a' picked %d/%d times, want close to %.0f (weight 3:1)", counts["a"], trials, wantA)
+	}
+}
+
+// TestWeightedRoundRobinLoadBalancer_Distribution checks that over many
+// picks, the EDF schedule's pick frequency converges to each backend's
+// weight share.
+func TestWeightedRoundRobinLoadBalancer_Distribution(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a", "b"})
+	reg.Backends["a"].Weight = 3
+	reg.Backends["b"].Weight = 1
+	lb := loadbalancer.NewWeightedRoundRobinLoadBalancer(reg)
+
+	const trials = 10000
+	counts := map[string]int{}
+	for i := 0; i < trials; i++ {
+		counts[lb.PickBackend()]++
+	}
+
+	wantA := float64(trials) * 3.0 / 4.0
+	gotA := float64(counts["a"])
+	if math.Abs(gotA-wantA)/wantA > 0.05 {
+		t.Errorf("backend 'a' picked %d/%d times, want close to %.0f (weight 3:1)", counts["a"], trials, wantA)
+	}
+}
+
+// TestLeastInFlightLoadBalancer_PrefersFewerInFlight verifies PickBackend
+// routes away from a backend with an outstanding Acquire until Release
+// brings its count back down, and settles on a deterministic pick (the
+// lower-sorted URL) once counts are tied.
+func TestLeastInFlightLoadBalancer_PrefersFewerInFlight(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a", "b"})
+	lb := loadbalancer.NewLeastInFlightLoadBalancer(reg)
+
+	if picked := lb.PickBackend(); picked != "a" {
+		t.Fatalf("PickBackend() = %v, want 'a' when in-flight counts are tied at 0", picked)
+	}
+
+	lb.Acquire("a")
+	lb.Acquire("a")
+	if picked := lb.PickBackend(); picked != "b" {
+		t.Fatalf("PickBackend() = %v, want 'b' while 'a' has 2 in flight", picked)
+	}
+
+	lb.Release("a")
+	lb.Release("a")
+	if picked := lb.PickBackend(); picked != "a" {
+		t.Fatalf("PickBackend() = %v, want 'a' once counts are tied again", picked)
+	}
+}
+
+// TestLeastInFlightLoadBalancer_AcquireDoesNotClobberWeight verifies that
+// Acquire/Release, which run on every proxied request, never reset a
+// backend's cached weight back to the default — only PickBackend's own
+// registry read should refresh it.
+func TestLeastInFlightLoadBalancer_AcquireDoesNotClobberWeight(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry(nil)
+	reg.RegisterBackendWithWeight("a", 10)
+	reg.RegisterBackendWithWeight("b", 1)
+	lb := loadbalancer.NewLeastInFlightLoadBalancer(reg)
+
+	// Seed PickBackend's weight cache, then drive a bunch of Acquire/Release
+	// pairs the way the proxy handler would for every request.
+	lb.PickBackend()
+	for i := 0; i < 5; i++ {
+		lb.Acquire("a")
+		lb.Release("a")
+	}
+
+	// With counts tied at 0 again, the tie-break must still prefer 'a' for
+	// its higher weight rather than falling back to a clobbered default.
+	if picked := lb.PickBackend(); picked != "a" {
+		t.Fatalf("PickBackend() = %v, want 'a' (higher weight) once counts are tied again", picked)
+	}
+}
+
+// TestLeastInFlightLoadBalancer_ReleaseDecrements verifies Release actually
+// drains the count Acquire incremented, rather than leaking it.
+func TestLeastInFlightLoadBalancer_ReleaseDecrements(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a", "b"})
+	lb := loadbalancer.NewLeastInFlightLoadBalancer(reg)
+
+	lb.Acquire("a")
+	if picked := lb.PickBackend(); picked != "b" {
+		t.Fatalf("PickBackend() = %v, want 'b' while 'a' has 1 in flight", picked)
+	}
+	lb.Release("a")
+	if picked := lb.PickBackend(); picked != "a" && picked != "b" {
+		t.Fatalf("PickBackend() = %v, want 'a' or 'b' once counts are equal again", picked)
+	}
+}
+
+// TestRendezvousHashLoadBalancer_StablePinning verifies the same key always
+// picks the same backend while the member set is unchanged.
+func TestRendezvousHashLoadBalancer_StablePinning(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a", "b", "c"})
+	lb := loadbalancer.NewRendezvousHashLoadBalancer(reg, loadbalancer.ConsistentHashKeyFunc("header:X-Session-Id"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+	req.Header.Set("X-Session-Id", "user-42")
+
+	first := lb.PickBackend(req, httptest.NewRecorder())
+	for i := 0; i < 10; i++ {
+		if got := lb.PickBackend(req, httptest.NewRecorder()); got != first {
+			t.Fatalf("PickBackend() = %v on call %d, want stable pick %v", got, i, first)
+		}
+	}
+}
+
+// TestRendezvousHashLoadBalancer_RedistributesOnRemoval verifies that
+// removing a backend only remaps the keys it used to own, leaving every
+// other key's pick unchanged.
+func TestRendezvousHashLoadBalancer_RedistributesOnRemoval(t *testing.T) {
+	urls := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	reg := registry.NewInMemoryBackendRegistry(urls)
+	lb := loadbalancer.NewRendezvousHashLoadBalancer(reg, loadbalancer.ConsistentHashKeyFunc("header:X-Session-Id"))
+
+	keys := []string{"k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9", "k10"}
+	before := make(map[string]string, len(keys))
+	for _, k := range keys {
+		req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+		req.Header.Set("X-Session-Id", k)
+		before[k] = lb.PickBackend(req, httptest.NewRecorder())
+	}
+
+	removed := before[keys[0]]
+	reg.Backends[removed].Healthy = false
+
+	for _, k := range keys {
+		req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+		req.Header.Set("X-Session-Id", k)
+		got := lb.PickBackend(req, httptest.NewRecorder())
+		if before[k] == removed {
+			if got == removed {
+				t.Fatalf("key %q still maps to removed backend %v", k, removed)
+			}
+			continue
+		}
+		if got != before[k] {
+			t.Fatalf("key %q remapped from %v to %v after an unrelated backend's removal", k, before[k], got)
+		}
+	}
+}
+
+// TestRendezvousHashLoadBalancer_FallsThroughWithNoBackends verifies
+// PickBackend returns "" rather than panicking when nothing is healthy.
+func TestRendezvousHashLoadBalancer_FallsThroughWithNoBackends(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"a"})
+	reg.Backends["a"].Healthy = false
+	lb := loadbalancer.NewRendezvousHashLoadBalancer(reg, loadbalancer.ConsistentHashKeyFunc("ip"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy/", nil)
+	if got := lb.PickBackend(req, httptest.NewRecorder()); got != "" {
+		t.Fatalf("PickBackend() = %v, want \"\" with no healthy backends", got)
+	}
+}
+
+// Test EWMALoadBalancer picks the backend with the lowest latency on first
+// sample, since with no prior EWMA history the first update bootstraps
+// directly to the observed sample.
+func TestEWMALoadBalancer_PickBackend(t *testing.T) {
+	reg := registry.NewInMemoryBackendRegistry([]string{"x", "y", "z"})
+	reg.Backends["x"].AverageLatencyMs = 100.0
+	reg.Backends["y"].AverageLatencyMs = 50.0
+	reg.Backends["z"].AverageLatencyMs = 200.0
+	lb := loadbalancer.NewEWMALoadBalancer(reg, 10*time.Second)
+	picked := lb.PickBackend()
+	if picked != "y" {
+		t.Errorf("EWMALoadBalancer picked %v, want 'y'", picked)
+	}
+}
@@ -0,0 +1,58 @@
+package test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/probe"
+	"github.com/Pranshu258/OpenPrequal/pkg/probe/probepb"
+)
+
+// stubProbeServer answers GetMetrics with a fixed snapshot so the test can
+// assert it survived the real wire codec unchanged.
+type stubProbeServer struct {
+	probepb.UnimplementedProbeServer
+}
+
+func (stubProbeServer) GetMetrics(ctx context.Context, req *probepb.ProbeRequest) (*probepb.ProbeResponse, error) {
+	return &probepb.ProbeResponse{
+		RequestsInFlight:  3,
+		AverageLatencyMs:  12.5,
+		RIFKeyedLatencyMs: 4.25,
+	}, nil
+}
+
+// TestGRPCProberReceivesMetricsFromLiveServer drives a real
+// Probe.GetMetrics round trip over a live grpc.Server/GRPCProber pair,
+// catching wire-format regressions (e.g. ProbeRequest/ProbeResponse failing
+// to marshal under grpc-go's codec) that a dial-failure-only test can't.
+func TestGRPCProberReceivesMetricsFromLiveServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	probepb.RegisterProbeServer(srv, stubProbeServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	prober := probe.NewGRPCProber(nil)
+	defer prober.Close()
+
+	resp, err := prober.ProbeBackend(lis.Addr().String())
+	if err != nil {
+		t.Fatalf("ProbeBackend() error = %v", err)
+	}
+	if resp.RequestsInFlight != 3 {
+		t.Errorf("RequestsInFlight = %v, want 3", resp.RequestsInFlight)
+	}
+	if resp.AverageLatencyMs != 12.5 {
+		t.Errorf("AverageLatencyMs = %v, want 12.5", resp.AverageLatencyMs)
+	}
+	if resp.RIFKeyedLatencyMs != 4.25 {
+		t.Errorf("RIFKeyedLatencyMs = %v, want 4.25", resp.RIFKeyedLatencyMs)
+	}
+}
@@ -0,0 +1,235 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+// fakeDiscoverySource is a DiscoverySource whose reported membership and
+// error can be swapped between Discover calls, so tests can exercise
+// DiscoveryBackendRegistry's reconciliation without a real Consul/etcd/k8s
+// backend.
+type fakeDiscoverySource struct {
+	mu        sync.Mutex
+	name      string
+	backends  []registry.DiscoveredBackend
+	err       error
+	discovers int
+}
+
+func (f *fakeDiscoverySource) Name() string { return f.name }
+
+func (f *fakeDiscoverySource) Discover(ctx context.Context) ([]registry.DiscoveredBackend, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.discovers++
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make([]registry.DiscoveredBackend, len(f.backends))
+	copy(out, f.backends)
+	return out, nil
+}
+
+func (f *fakeDiscoverySource) setBackends(b []registry.DiscoveredBackend) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backends = b
+}
+
+func (f *fakeDiscoverySource) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeDiscoverySource) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.discovers
+}
+
+// waitFor polls cond every 5ms until it returns true or timeout elapses,
+// failing the test if it never does.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met within %s", timeout)
+}
+
+func TestDiscoveryBackendRegistry_SyncsMembershipFromSource(t *testing.T) {
+	source := &fakeDiscoverySource{name: "fake"}
+	source.setBackends([]registry.DiscoveredBackend{
+		{URL: "http://b1", Weight: 2, Zone: "us-east"},
+		{URL: "http://b2"},
+	})
+
+	reg := registry.NewDiscoveryBackendRegistry(source, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reg.Start(ctx)
+	defer reg.Stop()
+
+	waitFor(t, time.Second, func() bool { return len(reg.ListBackends()) == 2 })
+
+	backends := reg.ListBackends()
+	byURL := make(map[string]*registry.BackendInfo, len(backends))
+	for _, b := range backends {
+		byURL[b.URL] = b
+	}
+	if byURL["http://b1"].Weight != 2 || byURL["http://b1"].Zone != "us-east" {
+		t.Fatalf("b1 metadata not applied: %+v", byURL["http://b1"])
+	}
+	if _, ok := byURL["http://b2"]; !ok {
+		t.Fatalf("expected b2 to be registered")
+	}
+
+	// Drop b2 and add b3; the next poll should deregister b2 and register b3.
+	source.setBackends([]registry.DiscoveredBackend{
+		{URL: "http://b1", Weight: 2, Zone: "us-east"},
+		{URL: "http://b3"},
+	})
+	waitFor(t, time.Second, func() bool {
+		urls := make(map[string]bool)
+		for _, b := range reg.ListBackends() {
+			urls[b.URL] = true
+		}
+		return urls["http://b1"] && urls["http://b3"] && !urls["http://b2"]
+	})
+}
+
+func TestDiscoveryBackendRegistry_FailedDiscoverLeavesMembershipUnchanged(t *testing.T) {
+	source := &fakeDiscoverySource{name: "fake"}
+	source.setBackends([]registry.DiscoveredBackend{{URL: "http://b1"}})
+
+	reg := registry.NewDiscoveryBackendRegistry(source, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reg.Start(ctx)
+	defer reg.Stop()
+
+	waitFor(t, time.Second, func() bool { return len(reg.ListBackends()) == 1 })
+
+	source.setErr(errors.New("discovery unavailable"))
+	waitFor(t, time.Second, func() bool { return source.callCount() >= 3 })
+
+	if got := reg.ListBackends(); len(got) != 1 || got[0].URL != "http://b1" {
+		t.Fatalf("expected membership to be unchanged after a failed poll, got %+v", got)
+	}
+}
+
+func TestDiscoveryBackendRegistry_StopHaltsPolling(t *testing.T) {
+	source := &fakeDiscoverySource{name: "fake"}
+	reg := registry.NewDiscoveryBackendRegistry(source, 5*time.Millisecond)
+	reg.Start(context.Background())
+
+	waitFor(t, time.Second, func() bool { return source.callCount() >= 2 })
+	reg.Stop()
+	stopped := source.callCount()
+
+	time.Sleep(50 * time.Millisecond)
+	if got := source.callCount(); got > stopped+1 {
+		t.Fatalf("expected polling to stop, calls grew from %d to %d", stopped, got)
+	}
+}
+
+func TestCompositeBackendRegistry_MergesAndDedupesByURL(t *testing.T) {
+	primary := registry.NewInMemoryBackendRegistry([]string{"http://primary", "http://shared"})
+	secondary := registry.NewInMemoryBackendRegistry([]string{"http://shared", "http://discovered"})
+
+	composite := registry.NewCompositeBackendRegistry(primary, secondary)
+	got := composite.ListBackends()
+
+	urls := make([]string, 0, len(got))
+	for _, b := range got {
+		urls = append(urls, b.URL)
+	}
+	want := []string{"http://discovered", "http://primary", "http://shared"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Fatalf("ListBackends() urls = %v, want %v", urls, want)
+	}
+
+	// primary's entry must win for the URL present in both sources.
+	for _, b := range got {
+		if b.URL == "http://shared" {
+			primaryEntry := primary.Backends["http://shared"]
+			if b != primaryEntry {
+				t.Fatalf("expected the shared backend to be primary's instance")
+			}
+		}
+	}
+}
+
+func TestCompositeBackendRegistry_WritesGoToPrimaryOnly(t *testing.T) {
+	primary := registry.NewInMemoryBackendRegistry(nil)
+	secondary := registry.NewInMemoryBackendRegistry(nil)
+	composite := registry.NewCompositeBackendRegistry(primary, secondary)
+
+	composite.RegisterBackend("http://a")
+	composite.RegisterBackendWithWeight("http://b", 3)
+	composite.SetHealthy("http://a", false)
+	composite.UpdateMetrics("http://a", 5, 12.5, 4.0)
+
+	if len(secondary.ListBackends()) != 0 {
+		t.Fatalf("expected writes not to reach the secondary source")
+	}
+	primaryBackends := primary.ListBackends()
+	if len(primaryBackends) != 2 {
+		t.Fatalf("expected 2 backends on primary, got %d", len(primaryBackends))
+	}
+	a := primary.Backends["http://a"]
+	if a.Healthy {
+		t.Fatalf("expected http://a to be unhealthy after SetHealthy(false)")
+	}
+	if a.RequestsInFlight != 5 || a.AverageLatencyMs != 12.5 {
+		t.Fatalf("UpdateMetrics not applied: %+v", a)
+	}
+	if primary.Backends["http://b"].Weight != 3 {
+		t.Fatalf("expected http://b weight 3, got %v", primary.Backends["http://b"].Weight)
+	}
+
+	composite.DeregisterBackend("http://a")
+	if _, ok := primary.Backends["http://a"]; ok {
+		t.Fatalf("expected http://a to be deregistered from primary")
+	}
+}
+
+func TestCompositeBackendRegistry_WatchStreamsPrimaryOnly(t *testing.T) {
+	primary := registry.NewInMemoryBackendRegistry(nil)
+	secondary := registry.NewInMemoryBackendRegistry(nil)
+	composite := registry.NewCompositeBackendRegistry(primary, secondary)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := composite.Watch(ctx)
+
+	composite.RegisterBackend("http://a")
+	secondary.RegisterBackend("http://ignored")
+
+	select {
+	case ev := <-ch:
+		if ev.URL != "http://a" {
+			t.Fatalf("expected event for http://a, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for primary's registration event")
+	}
+
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected no event from secondary's registration, got %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Pranshu258/OpenPrequal/pkg/registry"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+func boolPtr(v bool) *bool    { return &v }
+func strPtr(v string) *string { return &v }
+
+func TestKubernetesDiscoverySource_ListsReadyAddressesOnMatchedPort(t *testing.T) {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc123",
+			Namespace: "default",
+			Labels:    map[string]string{"kubernetes.io/service-name": "web"},
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{Name: strPtr("metrics"), Port: int32Ptr(9090)},
+			{Name: strPtr("http"), Port: int32Ptr(8080)},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discoveryv1.EndpointConditions{Ready: boolPtr(true)},
+				Zone:       strPtr("us-east-1a"),
+			},
+			{
+				// Not ready: must be excluded.
+				Addresses:  []string{"10.0.0.2"},
+				Conditions: discoveryv1.EndpointConditions{Ready: boolPtr(false)},
+			},
+		},
+	}
+	client := fake.NewSimpleClientset(slice)
+	source := registry.NewKubernetesDiscoverySource(client, "default", "web", "http", "https")
+
+	got, err := source.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 discovered backend, got %d: %+v", len(got), got)
+	}
+	want := registry.DiscoveredBackend{URL: "https://10.0.0.1:8080", Zone: "us-east-1a"}
+	if got[0] != want {
+		t.Fatalf("Discover() = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestKubernetesDiscoverySource_NoMatchingPortFallsBackToFirstPort(t *testing.T) {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc123",
+			Namespace: "default",
+			Labels:    map[string]string{"kubernetes.io/service-name": "web"},
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{Name: strPtr("metrics"), Port: int32Ptr(9090)},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.1"}},
+		},
+	}
+	client := fake.NewSimpleClientset(slice)
+	// portName "http" matches nothing, but the source still falls back to the
+	// slice's first listed port rather than skipping it.
+	source := registry.NewKubernetesDiscoverySource(client, "default", "web", "http", "")
+
+	got, err := source.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(got) != 1 || got[0].URL != "http://10.0.0.1:9090" {
+		t.Fatalf("Discover() = %+v, want fallback to first port 9090", got)
+	}
+}